@@ -1,12 +1,17 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/cli/commands/steps"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/cloney"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/git"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/metadata"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
 
 	"github.com/spf13/cobra"
@@ -14,7 +19,7 @@ import (
 
 // cloneCmdRun is the function that runs when the 'clone' command is called.
 func cloneCmdRun(cmd *cobra.Command, args []string) error {
-	if len(args) < 1 {
+	if compose, _ := cmd.Flags().GetString("compose"); len(args) < 1 && compose == "" {
 		terminal.ErrorMessage("You must provide a repository URL\n", nil)
 
 		// Display command help if no repository URL is provided.
@@ -23,67 +28,107 @@ func cloneCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get command-line arguments.
-	repositoryURL := args[0]
-	branch, _ := cmd.Flags().GetString("branch")
+	branch, tag := getBranchAndTag(cmd)
 	output, _ := cmd.Flags().GetString("output")
-	tag, _ := cmd.Flags().GetString("tag")
-	variables, _ := cmd.Flags().GetString("variables")
+	variablesSources := getUserVariablesSources(cmd)
 	token, _ := cmd.Flags().GetString("token")
+	force, _ := cmd.Flags().GetBool("force")
+	into, _ := cmd.Flags().GetString("into")
+	conflictStrategy, _ := cmd.Flags().GetString("conflict")
+	compose, _ := cmd.Flags().GetString("compose")
+	collisionStrategy, _ := cmd.Flags().GetString("collisions")
+
+	// Stop fetching and rendering when the command is interrupted or times out.
+	// The staging directory is deleted as on any other error, so nothing is left half-generated.
+	ctx, cancel := commandContext(cmd)
+	defer cancel()
 
 	// Variable to store errors.
 	var err error
 
+	// The 'into' flag merges the files into an existing directory, so it cannot be combined with 'output' or 'force'.
+	if into != "" && (output != "" || force) {
+		err = fmt.Errorf("the '--into' flag cannot be used with the '--output' or '--force' flags")
+		terminal.ErrorMessage("Invalid flags", err)
+		return err
+	}
+
 	// Get the current working directory.
 	currentDir, err := steps.GetCurrentWorkingDirectory()
 	if err != nil {
 		return err
 	}
 
-	// Get the template variables provided by the user.
-	variablesMap, err := steps.GetUserVariablesMap(currentDir, variables)
+	// Several templates, given as arguments or in a composition manifest, are composed into the same directory.
+	compositionTemplates, collisionStrategy, compositionDir, err := getCompositionTemplates(cmd, args, compose, collisionStrategy, currentDir)
 	if err != nil {
 		return err
 	}
 
-	// Create and validate the Git repository.
-	repository, err := steps.CreateAndValidateRepository(repositoryURL, branch, tag)
+	// Get the template variables provided by the user.
+	variablesMap, err := steps.GetUserVariablesMap(currentDir, variablesSources)
 	if err != nil {
 		return err
 	}
 
-	// If a token is provided, authenticate with it.
-	steps.AuthenticateToRepository(repository, token)
+	// Create and validate the Git repository, unless several templates are composed.
+	var repository *git.GitRepository
+	defaultName := ""
+	if compositionTemplates == nil {
+		repository, err = steps.CreateAndValidateRepository(steps.ResolveAlias(args[0]), branch, tag)
+		if err != nil {
+			return err
+		}
+		defaultName = repository.GetName()
 
-	// Calculate the clone path.
-	clonePath, _ := steps.CalculatePath(output, repository.GetName())
+		// If a token is provided, authenticate with it.
+		steps.AuthenticateToRepository(repository, token)
+	} else {
+		defaultName = steps.GetTemplateName(compositionTemplates[0].Source)
+	}
 
-	// Clone the repository.
-	err = steps.CloneRepository(repository, clonePath)
+	// Calculate the clone path.
+	// With the 'into' flag, the files are merged into an existing directory instead.
+	clonePath, _ := steps.CalculatePath(output, defaultName)
+	if into != "" {
+		clonePath, _ = steps.CalculatePath(into, "")
+		err = steps.ValidateConflictStrategy(conflictStrategy)
+	} else {
+		// Refuse to replace a non-empty directory unless the 'force' flag is set.
+		err = steps.CheckTargetDirectory(clonePath, force)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Read the repository metadata file.
-	metadataFilePath := filepath.Join(clonePath, appConfig.MetadataFileName)
-	metadataContent, err := steps.ReadRepositoryMetadata(metadataFilePath)
+	// Generate the files in a staging directory next to the clone path, which is only moved into place at the end.
+	// If anything fails, only the staging directory is deleted, so pre-existing content is never touched.
+	// With the 'into' flag, the files are merged instead of moved, so they are generated in a temporary directory.
+	staging, err := steps.CreateStagingDirectory(clonePath, into != "")
 	if err != nil {
-		// If it was not possible to read the metadata file, delete the cloned repository.
-		os.RemoveAll(clonePath)
 		return err
 	}
+	defer staging.Cleanup()
+	stagingPath := staging.Path
 
-	// Delete the repository metadata file.
-	os.Remove(metadataFilePath)
-
-	// Delete the .git directory.
-	gitDirPath := filepath.Join(clonePath, ".git")
-	os.RemoveAll(gitDirPath)
+	// Get the template files and metadata. A single template is cloned into the staging directory, while composed
+	// templates are fetched into temporary directories and used as the base templates of the empty staging directory.
+	var cloneyMetadata *metadata.CloneyMetadata
+	var bases []cloney.Base
+	var cleanupBases func()
+	if compositionTemplates == nil {
+		cloneyMetadata, bases, cleanupBases, err = cloneTemplateRepository(ctx, repository, stagingPath, token)
+	} else {
+		cloneyMetadata, bases, cleanupBases, err = steps.ComposeTemplates(ctx, compositionTemplates, collisionStrategy, compositionDir, token)
+	}
+	if err != nil {
+		return err
+	}
+	defer cleanupBases()
 
-	// Parse the metadata file.
-	cloneyMetadata, err := steps.ParseRepositoryMetadata(metadataContent, appConfig.SupportedManifestVersions)
+	// Prompt the user for the secret variables that are required but not defined.
+	err = steps.PromptSecretVariables(cloneyMetadata, variablesMap)
 	if err != nil {
-		// If it was not possible to parse the metadata file, delete the cloned repository.
-		os.RemoveAll(clonePath)
 		return err
 	}
 
@@ -91,59 +136,223 @@ func cloneCmdRun(cmd *cobra.Command, args []string) error {
 	// Also, fill default values of the variables if they are not defined.
 	err = steps.MatchUserVariables(cloneyMetadata, variablesMap)
 	if err != nil {
-		// If the user variables do not match the template variables, delete the cloned repository.
-		os.RemoveAll(clonePath)
 		return err
 	}
 
 	// Define options for ignoring specific files and directories when filling template variables.
-	var ignorePaths []string
-	ignorePaths = append(ignorePaths, appConfig.KnownIgnorePaths...)
-	ignorePaths = append(ignorePaths, cloneyMetadata.Configuration.IgnorePaths...)
+	ignorePaths, err := steps.GetIgnorePaths(stagingPath, cloneyMetadata)
+	if err != nil {
+		return err
+	}
+
+	// Fetch the partial libraries and find the partials directory.
+	partials, cleanupPartials, err := steps.GetPartials(ctx, stagingPath, cloneyMetadata, bases, token)
+	if err != nil {
+		return err
+	}
+	defer cleanupPartials()
 
 	// Set the 'outputInTerminal' parameter to 'false' because we intend to actually fill the template variables.
-	err = steps.FillDirectory(clonePath, ignorePaths, false, variablesMap)
+	err = steps.FillDirectory(ctx, stagingPath, ignorePaths, false, variablesMap, cloneyMetadata.Configuration, partials, bases)
 	if err != nil {
-		// If it was not possible to fill the template variables, delete the cloned repository.
-		os.RemoveAll(clonePath)
 		return err
 	}
 
 	// Delete the paths specified in the 'ignore_paths' field of the metadata file.
-	steps.DeleteIgnoredPaths(clonePath, ignorePaths)
+	steps.DeleteIgnoredPaths(stagingPath, ignorePaths)
+
+	// Move the generated files into place, or merge them into the existing directory.
+	result := generationResult{Output: clonePath}
+	if into != "" {
+		var report *templates.MergeReport
+		report, err = steps.MergeIntoDirectory(staging, conflictStrategy)
+		if report != nil {
+			result.MergeReport = *report
+		}
+	} else {
+		// The staging directory is listed before being moved into place, since it then holds only the generated files.
+		if terminal.IsStructuredOutput() {
+			result, err = listGeneratedFiles(stagingPath, clonePath)
+			if err != nil {
+				return err
+			}
+		}
+		err = steps.CommitStagingDirectory(staging)
+	}
+	if err != nil {
+		return err
+	}
 
 	terminal.Message("\nDone!")
 
+	// In the 'json' and 'yaml' output formats, print the files written and skipped.
+	if terminal.IsStructuredOutput() {
+		if result.Created == nil {
+			result.Created = []string{}
+		}
+		return printResult(result)
+	}
+
 	return nil
 }
 
+// getCompositionTemplates returns the templates to compose, from the arguments or from the composition manifest,
+// along with the collision strategy and the directory that local templates are relative to.
+// It returns nil templates if a single template is cloned.
+func getCompositionTemplates(cmd *cobra.Command, args []string, compose, collisionStrategy, currentDir string) ([]metadata.CompositionTemplate, string, string, error) {
+	if compose == "" {
+		if len(args) < 2 {
+			return nil, "", "", nil
+		}
+		branch, tag := getBranchAndTag(cmd)
+		var compositionTemplates []metadata.CompositionTemplate
+		for _, source := range args {
+			compositionTemplates = append(compositionTemplates, metadata.CompositionTemplate{Source: steps.ResolveAlias(source), Branch: branch, Tag: tag})
+		}
+		if err := metadata.ValidateCollisionStrategy(collisionStrategy); err != nil {
+			terminal.ErrorMessage("Invalid flags", err)
+			return nil, "", "", err
+		}
+		return compositionTemplates, collisionStrategy, currentDir, nil
+	}
+
+	if len(args) > 0 {
+		err := fmt.Errorf("the '--compose' flag cannot be used with repository arguments")
+		terminal.ErrorMessage("Invalid flags", err)
+		return nil, "", "", err
+	}
+	manifestPath, _ := steps.CalculatePath(compose, "")
+	manifest, err := metadata.NewCompositionManifestFromFile(manifestPath)
+	if err != nil {
+		terminal.ErrorMessage("Could not read the composition manifest", err)
+		return nil, "", "", err
+	}
+
+	// The templates of the manifest can also be aliases.
+	for index := range manifest.Templates {
+		manifest.Templates[index].Source = steps.ResolveAlias(manifest.Templates[index].Source)
+	}
+
+	// The collision strategy of the manifest is used, unless the flag is set.
+	if manifest.Collisions != "" && !cmd.Flags().Changed("collisions") {
+		collisionStrategy = manifest.Collisions
+	}
+	if err := metadata.ValidateCollisionStrategy(collisionStrategy); err != nil {
+		terminal.ErrorMessage("Invalid flags", err)
+		return nil, "", "", err
+	}
+	return manifest.Templates, collisionStrategy, filepath.Dir(manifestPath), nil
+}
+
+// cloneTemplateRepository clones a template repository into the staging directory, reads its metadata
+// and resolves its base templates.
+func cloneTemplateRepository(ctx context.Context, repository *git.GitRepository, stagingPath, token string) (*metadata.CloneyMetadata, []cloney.Base, func(), error) {
+	// Clone the repository.
+	err := steps.CloneRepository(ctx, repository, stagingPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Read the repository metadata file.
+	metadataFilePath := filepath.Join(stagingPath, appConfig.MetadataFileName)
+	metadataContent, err := steps.ReadRepositoryMetadata(metadataFilePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Delete the repository metadata file.
+	os.Remove(metadataFilePath)
+
+	// Delete the .git directory.
+	gitDirPath := filepath.Join(stagingPath, ".git")
+	os.RemoveAll(gitDirPath)
+
+	// Parse the metadata file.
+	cloneyMetadata, err := steps.ParseRepositoryMetadata(metadataContent, appConfig.SupportedManifestVersions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Find or fetch the base templates, and merge their metadata into the template repository metadata.
+	// Remote templates can only extend remote base templates, so they are not resolved against the staging directory.
+	return steps.ResolveExtends(ctx, "", cloneyMetadata, token)
+}
+
+// ResetCloneFlags resets the flags of the 'clone' command.
+func ResetCloneFlags(cloneCmd *cobra.Command) {
+	cloneCmd.Flags().Set("output", "")
+	cloneCmd.Flags().Set("branch", "main")
+	cloneCmd.Flags().Set("tag", "")
+	cloneCmd.Flags().Set("token", "")
+	cloneCmd.Flags().Set("force", "false")
+	cloneCmd.Flags().Set("into", "")
+	cloneCmd.Flags().Set("conflict", templates.SKIP_CONFLICT_STRATEGY)
+	cloneCmd.Flags().Set("compose", "")
+	cloneCmd.Flags().Set("collisions", metadata.ERROR_COLLISION_STRATEGY)
+	cloneCmd.Flags().Set("timeout", "0s")
+	resetOutputFormatFlag(cloneCmd)
+	// The collision strategy of a composition manifest and the default branch of the configuration are only used
+	// if the flags were not set.
+	cloneCmd.Flags().Lookup("collisions").Changed = false
+	cloneCmd.Flags().Lookup("branch").Changed = false
+	resetUserVariablesFlags(cloneCmd)
+}
+
 // CreateCloneCommand creates the 'clone' command and its respective flags.
 func CreateCloneCommand() *cobra.Command {
 	// cloneCmd represents the 'clone' command.
 	// This command is used to clone a template repository.
 	cloneCmd := &cobra.Command{
-		Use:   "clone [repository_url]",
+		Use:   "clone [repository_url...]",
 		Short: "Clone a template repository",
 		Long: fmt.Sprintf(`Clone a template repository.
 
 The 'cloney clone' command will search for a file named '%s' in your current directory by default.
-You can specify a different file or pass the variables inline as YAML using the '--variables' flag.`, appConfig.DefaultUserVariablesFileName),
+You can specify different files (YAML, JSON, TOML or .env) or pass the variables inline as YAML using the '--variables' flag.
+Variables can also be defined in environment variables named 'CLONEY_VAR_<name>', or with the '--set', '--set-string' and '--set-file' flags.
+Secret variables that are required but not defined are prompted for, without echoing the typed characters.
+
+The files are generated in a staging directory and only moved into place once everything succeeded.
+Use '--into' to add the template files to an existing directory, such as an existing repository. Files that already
+exist are handled with the '--conflict' strategy, and a report of created, overwritten and skipped files is printed at the end.
+
+Several templates, such as a base service template followed by add-on templates, can be generated into the same directory
+by passing several repositories, or a composition manifest with '--compose'. Their variables share a single namespace.
+Files generated by more than one template are refused, unless '--collisions order' keeps the file of the last template declared.
+
+%s
+
+%s`, appConfig.DefaultUserVariablesFileName, variablesPrecedenceHelp, configurationHelp),
 		Example: strings.Join([]string{
 			"  clone https://github.com/username/repository.git",
+			"  clone my-alias -o ./my-project",
 			"  clone https://github.com/username/repository.git -v variables.yaml",
+			"  clone https://github.com/username/repository.git -v variables.json",
 			"  clone https://github.com/username/repository.git -v '{ var1: value, var2: value }'",
+			"  clone https://github.com/username/repository.git -v base.yaml -v prod.yaml --set db.port=5432",
+			"  clone https://github.com/username/repository.git -o ./existing-directory --force",
+			"  clone https://github.com/username/repository.git --into . --conflict backup",
+			"  clone https://github.com/username/service.git https://github.com/username/database.git -o ./my-service",
+			"  clone --compose ./cloney-compose.yaml -o ./my-service --collisions order",
 		}, "\n"),
-		Aliases:          []string{"cl"},
-		PersistentPreRun: persistentPreRun,
-		RunE:             cloneCmdRun,
+		Aliases:           []string{"cl"},
+		PersistentPreRunE: persistentPreRun,
+		RunE:              cloneCmdRun,
 	}
 
 	// Define command-line flags for the 'clone' command.
 	cloneCmd.Flags().StringP("output", "o", "", "Path to clone the repository to")
-	cloneCmd.Flags().StringP("branch", "b", "main", "Git branch")
+	cloneCmd.Flags().StringP("branch", "b", "main", "Git branch, if no tag is given (defaults to the 'default_branch' of the configuration)")
 	cloneCmd.Flags().StringP("tag", "t", "", "Git tag")
-	cloneCmd.Flags().StringP("variables", "v", appConfig.DefaultUserVariablesFileName, "Path to a template variables file or raw YAML")
+	addUserVariablesFlags(cloneCmd)
 	cloneCmd.Flags().StringP("token", "k", "", "Git token, if referencing a private Git repository (not recommended)")
+	cloneCmd.Flags().Bool("force", false, "Replace the output directory if it already exists and is not empty")
+	cloneCmd.Flags().String("into", "", "Path to an existing directory to generate the files into, keeping its other files")
+	cloneCmd.Flags().String("conflict", templates.SKIP_CONFLICT_STRATEGY, fmt.Sprintf("Strategy for files that already exist when using '--into' (%s)", strings.Join(templates.SupportedConflictStrategies, ", ")))
+	cloneCmd.Flags().String("compose", "", "Path to a composition manifest listing several templates to generate into the same directory")
+	cloneCmd.Flags().String("collisions", metadata.ERROR_COLLISION_STRATEGY, fmt.Sprintf("Strategy for files generated by more than one composed template (%s)", strings.Join(metadata.SupportedCollisionStrategies, ", ")))
+	addTimeoutFlag(cloneCmd)
+	setHasResult(cloneCmd)
 
 	return cloneCmd
 }
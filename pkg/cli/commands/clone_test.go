@@ -1,3 +1,308 @@
 package commands
 
-// TODO: Add 'clone' command tests.
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCloneCommand represents a command instance used for testing.
+var testCloneCommand = newTestCommand(CreateCloneCommand())
+
+// CreateDummyComposedTemplates creates two local templates to be composed: a service template and an add-on template.
+// Both generate a 'README.md' file, and they share the 'app_name' variable.
+func CreateDummyComposedTemplates(assert *assert.Assertions) {
+	serviceMetadata := `
+manifest_version: v1
+name: Service
+template_version: 0.0.0
+variables:
+  - name: app_name
+    example: MyApp
+`
+	writeDummyTemplateFile(assert, "test-clone-service", appConfig.MetadataFileName, serviceMetadata)
+	writeDummyTemplateFile(assert, "test-clone-service", "README.md", "# [[ .app_name ]]")
+	writeDummyTemplateFile(assert, "test-clone-service", "main.go", "package [[ .app_name | lower ]]")
+
+	addonMetadata := `
+manifest_version: v1
+name: Database
+template_version: 0.0.0
+variables:
+  - name: app_name
+    example: MyApp
+  - name: db_port
+    default: 5432
+    example: 5432
+`
+	writeDummyTemplateFile(assert, "test-clone-database", appConfig.MetadataFileName, addonMetadata)
+	writeDummyTemplateFile(assert, "test-clone-database", "README.md", "# [[ .app_name ]] with a database")
+	writeDummyTemplateFile(assert, "test-clone-database", "db.yaml", "name: [[ .app_name ]]\nport: [[ .db_port ]]")
+}
+
+// TestCloneCommandComposingTemplatesWithCollisions tests the "clone" command when several local templates
+// generate the same file. It should return an error and generate nothing.
+func TestCloneCommandComposingTemplatesWithCollisions(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create the templates to compose.
+	CreateDummyComposedTemplates(assert)
+
+	// Simulate CLI arguments to specify the templates and the output directory.
+	ResetCloneFlags(testCloneCommand)
+	testCloneCommand.SetArgs([]string{"./test-clone-service", "./test-clone-database", "-o", "test-clone-output", "-v", "{app_name: MyApp}"})
+
+	// Execute the "clone" command.
+	err := testCloneCommand.Execute()
+
+	// Assert that the "clone" command returned an error and that the output directory was not created.
+	assert.NotNil(err)
+	_, err = os.Stat("test-clone-output")
+	assert.True(os.IsNotExist(err))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-clone-service")
+	os.RemoveAll("test-clone-database")
+}
+
+// TestCloneCommandComposingTemplatesInOrder tests the "clone" command with a composition manifest
+// whose collisions are resolved by the declared order. It should generate the files of both templates.
+func TestCloneCommandComposingTemplatesInOrder(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create the templates to compose and the composition manifest.
+	CreateDummyComposedTemplates(assert)
+	manifest := `
+templates:
+  - source: ./test-clone-service
+  - source: ./test-clone-database
+collisions: order
+`
+	err := os.WriteFile("test-clone-compose.yaml", []byte(manifest), os.ModePerm)
+	assert.NoError(err)
+
+	// Simulate CLI arguments to specify the composition manifest and the output directory.
+	ResetCloneFlags(testCloneCommand)
+	testCloneCommand.SetArgs([]string{"--compose", "test-clone-compose.yaml", "-o", "test-clone-output", "-v", "{app_name: MyApp}"})
+
+	// Execute the "clone" command.
+	err = testCloneCommand.Execute()
+
+	// Assert that the "clone" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that the files of both templates were generated, and that the last template won the collision.
+	content, err := os.ReadFile(filepath.Join("test-clone-output", "README.md"))
+	assert.NoError(err)
+	assert.Equal("# MyApp with a database", string(content))
+	content, err = os.ReadFile(filepath.Join("test-clone-output", "main.go"))
+	assert.NoError(err)
+	assert.Equal("package myapp", string(content))
+	content, err = os.ReadFile(filepath.Join("test-clone-output", "db.yaml"))
+	assert.NoError(err)
+	assert.Equal("name: MyApp\nport: 5432", string(content))
+	_, err = os.Stat(filepath.Join("test-clone-output", appConfig.MetadataFileName))
+	assert.True(os.IsNotExist(err))
+
+	// Delete the created files and directories after the test.
+	os.RemoveAll("test-clone-service")
+	os.RemoveAll("test-clone-database")
+	os.RemoveAll("test-clone-output")
+	os.Remove("test-clone-compose.yaml")
+}
+
+// TestCloneCommandComposingTemplatesWithDifferentDelimiters tests the "clone" command when the composed templates
+// use different action delimiters. It should return an error and generate nothing.
+func TestCloneCommandComposingTemplatesWithDifferentDelimiters(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create the templates to compose, the second one with its own delimiters.
+	CreateDummyComposedTemplates(assert)
+	chartMetadata := `
+manifest_version: v1
+name: Chart
+template_version: 0.0.0
+configuration:
+  delimiters: ["<%", "%>"]
+variables:
+  - name: app_name
+    example: MyApp
+`
+	writeDummyTemplateFile(assert, "test-clone-chart", appConfig.MetadataFileName, chartMetadata)
+	writeDummyTemplateFile(assert, "test-clone-chart", "chart.yaml", "name: <% .app_name %>")
+
+	// Simulate CLI arguments to specify the templates and the output directory.
+	ResetCloneFlags(testCloneCommand)
+	testCloneCommand.SetArgs([]string{"./test-clone-service", "./test-clone-chart", "-o", "test-clone-output", "-v", "{app_name: MyApp}"})
+
+	// Execute the "clone" command.
+	err := testCloneCommand.Execute()
+
+	// Assert that the "clone" command returned an error and that the output directory was not created.
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "delimiters")
+	}
+	_, err = os.Stat("test-clone-output")
+	assert.True(os.IsNotExist(err))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-clone-service")
+	os.RemoveAll("test-clone-database")
+	os.RemoveAll("test-clone-chart")
+}
+
+// TestCloneCommandComposingTemplatesScopesConfiguration tests the "clone" command when a composed template defines
+// ignore paths and copy-only patterns. They should only apply to the files of that template.
+func TestCloneCommandComposingTemplatesScopesConfiguration(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create the templates to compose. The service template ignores its 'docs' directory and copies its markdown files.
+	CreateDummyComposedTemplates(assert)
+	serviceMetadata := `
+manifest_version: v1
+name: Service
+template_version: 0.0.0
+configuration:
+  ignore_paths: ["docs/"]
+  copy_only: ["*.md"]
+variables:
+  - name: app_name
+    example: MyApp
+`
+	writeDummyTemplateFile(assert, "test-clone-service", appConfig.MetadataFileName, serviceMetadata)
+	writeDummyTemplateFile(assert, "test-clone-service", "CONTRIBUTING.md", "Run [[ .app_name ]] locally.")
+	writeDummyTemplateFile(assert, filepath.Join("test-clone-service", "docs"), "guide.md", "Service guide.")
+	writeDummyTemplateFile(assert, filepath.Join("test-clone-database", "docs"), "guide.md", "[[ .app_name ]] database guide.")
+
+	// Simulate CLI arguments to specify the templates, the collision strategy and the output directory.
+	ResetCloneFlags(testCloneCommand)
+	testCloneCommand.SetArgs([]string{"./test-clone-database", "./test-clone-service", "-o", "test-clone-output", "-v", "{app_name: MyApp}", "--collisions", "order"})
+
+	// Execute the "clone" command.
+	err := testCloneCommand.Execute()
+	assert.Nil(err)
+
+	// The ignore paths of the service template do not hide the files of the database template.
+	content, err := os.ReadFile(filepath.Join("test-clone-output", "docs", "guide.md"))
+	assert.NoError(err)
+	assert.Equal("MyApp database guide.", string(content))
+
+	// The copy-only patterns of the service template only apply to its own files.
+	content, err = os.ReadFile(filepath.Join("test-clone-output", "CONTRIBUTING.md"))
+	assert.NoError(err)
+	assert.Equal(withDefaultDelimiters("Run [[ .app_name ]] locally."), string(content))
+	content, err = os.ReadFile(filepath.Join("test-clone-output", "README.md"))
+	assert.NoError(err)
+	assert.Equal(withDefaultDelimiters("# [[ .app_name ]]"), string(content))
+	content, err = os.ReadFile(filepath.Join("test-clone-output", "db.yaml"))
+	assert.NoError(err)
+	assert.Equal("name: MyApp\nport: 5432", string(content))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-clone-service")
+	os.RemoveAll("test-clone-database")
+	os.RemoveAll("test-clone-output")
+}
+
+// TestCloneCommandWhenOutputDirectoryIsNotEmpty tests the "clone" command when the output directory already exists
+// and is not empty. It should return an error and keep the directory, unless the '--force' flag replaces it.
+func TestCloneCommandWhenOutputDirectoryIsNotEmpty(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create the template to clone, with a composition manifest so that it is read from the disk, and an existing
+	// output directory. The output directory is named 'previous', like the directory where it is moved aside when replaced.
+	CreateDummyComposedTemplates(assert)
+	writeDummyTemplateFile(assert, ".", "test-clone-compose.yaml", "templates:\n  - source: ./test-clone-service")
+	outputPath := filepath.Join(t.TempDir(), "previous")
+	writeDummyTemplateFile(assert, outputPath, "old.txt", "old")
+
+	// Execute the "clone" command without the '--force' flag.
+	ResetCloneFlags(testCloneCommand)
+	testCloneCommand.SetArgs([]string{"--compose", "test-clone-compose.yaml", "-o", outputPath, "-v", "{app_name: MyApp}"})
+	err := testCloneCommand.Execute()
+
+	// Assert that the "clone" command returned an error and that the output directory was not touched.
+	assert.NotNil(err)
+	assert.FileExists(filepath.Join(outputPath, "old.txt"))
+	assert.NoFileExists(filepath.Join(outputPath, "README.md"))
+
+	// Execute the "clone" command with the '--force' flag.
+	ResetCloneFlags(testCloneCommand)
+	testCloneCommand.SetArgs([]string{"--compose", "test-clone-compose.yaml", "-o", outputPath, "-v", "{app_name: MyApp}", "--force"})
+	err = testCloneCommand.Execute()
+
+	// Assert that the "clone" command did not return an error and that the output directory was replaced.
+	assert.Nil(err)
+	assert.NoFileExists(filepath.Join(outputPath, "old.txt"))
+	content, err := os.ReadFile(filepath.Join(outputPath, "README.md"))
+	assert.NoError(err)
+	assert.Equal("# MyApp", string(content))
+	entries, err := os.ReadDir(filepath.Dir(outputPath))
+	assert.NoError(err)
+	assert.Len(entries, 1)
+
+	// Delete the created files and directories after the test.
+	os.RemoveAll("test-clone-service")
+	os.RemoveAll("test-clone-database")
+	os.Remove("test-clone-compose.yaml")
+}
+
+// TestCloneCommandIntoExistingDirectory tests the "clone" command with the '--into' flag. It should merge the generated
+// files into the existing directory, keeping the conflicting files, and leave nothing next to the directory.
+func TestCloneCommandIntoExistingDirectory(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create the template to clone, with a composition manifest so that it is read from the disk, and an existing
+	// directory with a file that conflicts with a generated file.
+	CreateDummyComposedTemplates(assert)
+	writeDummyTemplateFile(assert, ".", "test-clone-compose.yaml", "templates:\n  - source: ./test-clone-service")
+	intoPath := filepath.Join(t.TempDir(), "existing")
+	writeDummyTemplateFile(assert, intoPath, "README.md", "# Existing")
+
+	// Capture the output of the command.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Execute the "clone" command with the '--into' flag and the default conflict strategy.
+	ResetCloneFlags(testCloneCommand)
+	testCloneCommand.SetArgs([]string{"--compose", "test-clone-compose.yaml", "--into", intoPath, "-v", "{app_name: MyApp}", "--output-format", "json"})
+	err := testCloneCommand.Execute()
+	ResetCloneFlags(testCloneCommand)
+
+	// Assert that the "clone" command did not return an error and reported what happened to each file.
+	assert.Nil(err)
+	var result generationResult
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &result))
+	assert.Equal([]string{"main.go"}, result.Created)
+	assert.Equal([]string{"README.md"}, result.Skipped)
+
+	// Assert that the generated file was created, that the conflicting file was kept,
+	// and that nothing was left next to the directory.
+	content, err := os.ReadFile(filepath.Join(intoPath, "main.go"))
+	assert.NoError(err)
+	assert.Equal("package myapp", string(content))
+	content, err = os.ReadFile(filepath.Join(intoPath, "README.md"))
+	assert.NoError(err)
+	assert.Equal("# Existing", string(content))
+	entries, err := os.ReadDir(filepath.Dir(intoPath))
+	assert.NoError(err)
+	assert.Len(entries, 1)
+
+	// Delete the created files and directories after the test.
+	os.RemoveAll("test-clone-service")
+	os.RemoveAll("test-clone-database")
+	os.Remove("test-clone-compose.yaml")
+}
@@ -36,11 +36,11 @@ func docsCmdRun(cmd *cobra.Command, args []string) error {
 // CreateDocsCommand creates the 'docs' command.
 func CreateDocsCommand() *cobra.Command {
 	docsCmd := &cobra.Command{
-		Use:              "docs",
-		Short:            "Open the Cloney documentation in your browser",
-		Long:             "Open the Cloney documentation in your browser.",
-		PersistentPreRun: persistentPreRun,
-		RunE:             docsCmdRun,
+		Use:               "docs",
+		Short:             "Open the Cloney documentation in your browser",
+		Long:              "Open the Cloney documentation in your browser.",
+		PersistentPreRunE: persistentPreRun,
+		RunE:              docsCmdRun,
 	}
 
 	return docsCmd
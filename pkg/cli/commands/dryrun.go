@@ -2,13 +2,13 @@ package commands
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/cli/commands/steps"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/metadata"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
 
@@ -37,7 +37,19 @@ func dryRunCmdRun(cmd *cobra.Command, args []string) error {
 	output, _ := cmd.Flags().GetString("output")
 	outputInTerminal, _ := cmd.Flags().GetBool("output-in-terminal")
 	hotReload, _ := cmd.Flags().GetBool("hot-reload")
-	variables, _ := cmd.Flags().GetString("variables")
+	variablesSources := getUserVariablesSources(cmd)
+	printVariables, _ := cmd.Flags().GetBool("print-variables")
+
+	// A result is printed for each run, so the machine-readable output formats cannot be used while watching for changes.
+	if hotReload && terminal.IsStructuredOutput() {
+		err = fmt.Errorf("the '--hot-reload' flag cannot be used with the 'json' and 'yaml' output formats")
+		terminal.ErrorMessage("Invalid flags", err)
+		return err
+	}
+
+	// Stop fetching, rendering and watching when the command is interrupted or times out.
+	ctx, cancel := commandContext(cmd)
+	defer cancel()
 
 	// Get the current working directory.
 	currentDir, err := steps.GetCurrentWorkingDirectory()
@@ -46,9 +58,12 @@ func dryRunCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get the template variables provided by the user.
-	variablesMap, err := steps.GetUserVariablesMap(currentDir, variables)
-	if err != nil && !hotReload {
-		return err
+	variablesMap, err := steps.GetUserVariablesMap(currentDir, variablesSources)
+	if err != nil {
+		if !hotReload {
+			return err
+		}
+		variablesMap = map[string]interface{}{}
 	}
 
 	// Calculate the directory paths.
@@ -68,6 +83,19 @@ func dryRunCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Find or fetch the base templates, and merge their metadata into the template repository metadata.
+	cloneyMetadata, bases, cleanupBases, err := steps.ResolveExtends(ctx, sourcePath, cloneyMetadata, "")
+	if err != nil {
+		return err
+	}
+	defer cleanupBases()
+
+	// Prompt the user for the secret variables that are required but not defined.
+	err = steps.PromptSecretVariables(cloneyMetadata, variablesMap)
+	if err != nil && !hotReload {
+		return err
+	}
+
 	// Validate if the user variables match the template variables.
 	// Also, fill default values of the variables if they are not defined.
 	err = steps.MatchUserVariables(cloneyMetadata, variablesMap)
@@ -75,41 +103,69 @@ func dryRunCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// If the 'printVariables' flag is set, print the final merged variables instead of filling the template.
+	if printVariables {
+		return steps.PrintUserVariables(cloneyMetadata, variablesMap)
+	}
+
 	// Define options for ignoring specific files and directories when filling template variables.
-	ignorePaths := []string{
-		filepath.Base(filepath.Join(currentDir, variables)),
+	// The variables files inside the template directory are ignored, so they are not part of the output.
+	ignorePaths, err := steps.GetIgnorePaths(sourcePath, cloneyMetadata)
+	if err != nil {
+		return err
 	}
-	ignorePaths = append(ignorePaths, appConfig.KnownIgnorePaths...)
-	ignorePaths = append(ignorePaths, cloneyMetadata.Configuration.IgnorePaths...)
+	for _, variablesSource := range variablesSources.Files {
+		if metadata.IsInlineUserVariables(variablesSource) {
+			continue
+		}
+		variablesPath, err := steps.CalculatePath(variablesSource, "")
+		if err != nil {
+			return err
+		}
+		if relativeVariablesPath, err := filepath.Rel(sourcePath, variablesPath); err == nil && !strings.HasPrefix(relativeVariablesPath, "..") {
+			ignorePaths = append(ignorePaths, "/"+filepath.ToSlash(relativeVariablesPath))
+		}
+	}
+
+	// The output directory is ignored if it is inside the template directory, so previous results are not rendered again.
+	if relativeOutputPath, err := filepath.Rel(sourcePath, outputPath); err == nil && !strings.HasPrefix(relativeOutputPath, "..") && relativeOutputPath != "." {
+		ignorePaths = append(ignorePaths, "/"+filepath.ToSlash(relativeOutputPath)+"/")
+	}
+
+	// Fetch the partial libraries and find the partials directory.
+	partials, cleanupPartials, err := steps.GetPartials(ctx, sourcePath, cloneyMetadata, bases, "")
+	if err != nil {
+		return err
+	}
+	defer cleanupPartials()
 
 	// Check if the output should be displayed in the terminal.
-	if outputInTerminal {
+	// Files displayed in the terminal are rendered with the values of the secret variables replaced by "********".
+	// In the 'json' and 'yaml' output formats, the files are rendered in memory and printed with their contents.
+	var result generationResult
+	if outputInTerminal && terminal.IsStructuredOutput() {
+		var rendered *templates.MemoryFS
+		rendered, err = steps.RenderFiles(ctx, sourcePath, ignorePaths, cloneyMetadata.RedactSecrets(variablesMap), cloneyMetadata.Configuration, partials, bases)
+		if err == nil {
+			result = listRenderedFiles(rendered)
+		}
+	} else if outputInTerminal {
 		// Fill the template variables and display the output in the terminal instead of creating the files.
-		err = steps.FillDirectory(sourcePath, ignorePaths, true, variablesMap)
+		err = steps.FillDirectory(ctx, sourcePath, ignorePaths, true, cloneyMetadata.RedactSecrets(variablesMap), cloneyMetadata.Configuration, partials, bases)
 	} else {
-		// Delete the output directory if it already exists.
-		// This is necessary to avoid conflicts when creating the output directory.
-		os.RemoveAll(outputPath)
-
-		// Create a new directory to save the filled template files.
-		err = templates.CopyDirectory(sourcePath, outputPath, ignorePaths)
-		if err != nil && !hotReload {
-			return fmt.Errorf("error creating output directory %s: %w", outputPath, err)
-		}
-
-		// Fill the template variables in the output directory.
-		err = steps.FillDirectory(outputPath, ignorePaths, false, variablesMap)
+		// Render the template files into the output directory.
+		// The output directory is only replaced if all files were rendered successfully.
+		err = steps.RenderDirectory(ctx, sourcePath, outputPath, ignorePaths, variablesMap, cloneyMetadata.Configuration, partials, bases)
 
 		// Delete files and directories starting with "_" (Ignore Prefix).
 		// These are files that should be processed by Cloney but not copied to the output directory.
 		steps.DeleteIgnoredPaths(outputPath, ignorePaths)
+		if err == nil && terminal.IsStructuredOutput() {
+			result, err = listGeneratedFiles(outputPath, outputPath)
+		}
 	}
 
 	if err != nil && !hotReload {
-		// If it was not possible to fill the template variables, delete the created directory (if not a dry run).
-		if !outputInTerminal {
-			os.RemoveAll(outputPath)
-		}
 		return err
 	}
 
@@ -118,6 +174,11 @@ func dryRunCmdRun(cmd *cobra.Command, args []string) error {
 		terminal.Message("\nDone!")
 	}
 
+	// In the 'json' and 'yaml' output formats, print the files generated.
+	if terminal.IsStructuredOutput() {
+		return printResult(result)
+	}
+
 	// If hot reload mode was enabled, watch for changes in the template repository and re-run the command.
 	if hotReload {
 		currentTime := time.Now().Format("15:04:05")
@@ -137,8 +198,8 @@ func dryRunCmdRun(cmd *cobra.Command, args []string) error {
 		// Do not monitor the output directory.
 		ignorePaths = append(ignorePaths, filepath.Base(outputPath))
 
-		// Start watching for changes.
-		templates.WatchDirectory(watcher, sourcePath, ignorePaths, func() {
+		// Start watching for changes, until the command is interrupted.
+		templates.WatchDirectory(ctx, watcher, sourcePath, ignorePaths, func() {
 			currentTime := time.Now().Format("15:04:05")
 			terminal.Messagef("[%s] Changes detected, reloading...\n\n", terminal.Blue(currentTime))
 
@@ -157,8 +218,11 @@ func dryRunCmdRun(cmd *cobra.Command, args []string) error {
 func ResetDryRunFlags(dryRunCmd *cobra.Command) {
 	dryRunCmd.Flags().Set("output", appConfig.DefaultDryRunDirectoryName)
 	dryRunCmd.Flags().Set("output-in-terminal", "false")
-	dryRunCmd.Flags().Set("variables", appConfig.DefaultUserVariablesFileName)
 	dryRunCmd.Flags().Set("hot-reload", "false")
+	dryRunCmd.Flags().Set("print-variables", "false")
+	dryRunCmd.Flags().Set("timeout", "0s")
+	resetOutputFormatFlag(dryRunCmd)
+	resetUserVariablesFlags(dryRunCmd)
 }
 
 // CreateDryRunCommand creates the 'dry-run' command and its respective flags.
@@ -175,23 +239,33 @@ The 'cloney dry-run' command is for debugging purposes.
 With this command, you can check the output your template repository will generate with the given variables.
 
 By default, 'cloney dry-run' searches for a file named '%s' in your current directory.
-You can specify a different file or pass the variables inline as YAML using the '--variables' flag.`, appConfig.DefaultUserVariablesFileName),
+You can specify different files (YAML, JSON, TOML or .env) or pass the variables inline as YAML using the '--variables' flag.
+Variables can also be defined in environment variables named 'CLONEY_VAR_<name>', or with the '--set', '--set-string' and '--set-file' flags.
+Secret variables that are required but not defined are prompted for, without echoing the typed characters.
+Use the '--print-variables' flag to check the final variables after merging all sources.
+
+%s`, appConfig.DefaultUserVariablesFileName, variablesPrecedenceHelp),
 		Example: strings.Join([]string{
 			"  dry-run",
 			"  dry-run ./path/to/my/template",
 			"  dry-run ./path/to/my/template -v variables.yaml",
+			"  dry-run ./path/to/my/template -v variables.toml",
 			"  dry-run ./path/to/my/template -v '{ var1: value, var2: value }'",
+			"  dry-run ./path/to/my/template -v base.yaml -v prod.yaml --set services[0].name=api --print-variables",
 		}, "\n"),
-		Aliases:          []string{"dryrun", "dr", "fill"},
-		PersistentPreRun: persistentPreRun,
-		RunE:             dryRunCmdRun,
+		Aliases:           []string{"dryrun", "dr", "fill"},
+		PersistentPreRunE: persistentPreRun,
+		RunE:              dryRunCmdRun,
 	}
 
 	// Define command-line flags for the 'dryrun' command.
 	dryRunCmd.Flags().StringP("output", "o", appConfig.DefaultDryRunDirectoryName, "Path to output the filled template files")
 	dryRunCmd.Flags().BoolP("output-in-terminal", "i", false, "Output the filled template file contents in the terminal instead of creating the files")
 	dryRunCmd.Flags().BoolP("hot-reload", "r", false, "Enable hot reload mode")
-	dryRunCmd.Flags().StringP("variables", "v", appConfig.DefaultUserVariablesFileName, "Path to a template variables file or raw YAML")
+	dryRunCmd.Flags().Bool("print-variables", false, "Print the final template variables after merging all sources, instead of filling the template")
+	addUserVariablesFlags(dryRunCmd)
+	addTimeoutFlag(dryRunCmd)
+	setHasResult(dryRunCmd)
 
 	return dryRunCmd
 }
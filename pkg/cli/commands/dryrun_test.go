@@ -1,15 +1,20 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
 	"github.com/stretchr/testify/assert"
 )
 
 // testDryRunCommand represents a command instance used for testing.
-var testDryRunCommand = CreateDryRunCommand()
+var testDryRunCommand = newTestCommand(CreateDryRunCommand())
 
 // CreateDummyVariablesFile creates a dummy Cloney variables file in the specified directory.
 func CreateDummyVariablesFile(assert *assert.Assertions, directory string) {
@@ -107,4 +112,820 @@ func TestDryRunCommandWhenCurrentDirectoryIsACloneyProject(t *testing.T) {
 	assert.NoError(err)
 }
 
+// TestDryRunCommandWithJSONVariablesFileAndEnvironmentVariables tests the "dry-run" command
+// when the variables are defined in a JSON file and in environment variables.
+// Environment variables should take precedence over the file.
+func TestDryRunCommandWithJSONVariablesFileAndEnvironmentVariables(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-dry-run-project")
+
+	// Create a file referencing the variables.
+	writeDummyTemplateFile(assert, "test-dry-run-project", "dummy.txt", "[[ .app_name ]] [[ .dark_mode ]] [[ len .currencies ]]")
+
+	// Create a file in the template with the same name as the variables file, which is outside the template.
+	writeDummyTemplateFile(assert, filepath.Join("test-dry-run-project", "config"), "test-variables.json", "kept")
+
+	// Create a JSON variables file.
+	rawVariables := `{
+  "app_name": "JSONProject",
+  "dark_mode": false,
+  "currencies": [{ "name": "USD", "symbol": "$", "description": "United States Dollar" }]
+}`
+	err := os.WriteFile("test-variables.json", []byte(rawVariables), os.ModePerm)
+	assert.NoError(err)
+
+	// Override a variable with an environment variable. Its value should be parsed as a boolean.
+	t.Setenv("CLONEY_VAR_dark_mode", "true")
+
+	// Simulate CLI arguments to specify the project directory, the output directory and the variables file.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "test-variables.json"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that the variables were filled with the values from the file and the environment variable.
+	content, err := os.ReadFile(filepath.Join("test-dry-run-output", "dummy.txt"))
+	assert.NoError(err)
+	assert.Equal("JSONProject true 1", string(content))
+
+	// Assert that the template file with the same name as the variables file was not ignored.
+	assert.FileExists(filepath.Join("test-dry-run-output", "config", "test-variables.json"))
+
+	// Delete the created files and directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+	os.Remove("test-variables.json")
+}
+
+// TestDryRunCommandWithLayeredVariables tests the "dry-run" command when the variables are defined
+// in multiple sources. Later files and '--set' values should take precedence over earlier ones.
+func TestDryRunCommandWithLayeredVariables(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-dry-run-project")
+
+	// Create a file referencing the variables.
+	writeDummyTemplateFile(assert, "test-dry-run-project", "dummy.txt", "[[ .app_name ]] [[ .dark_mode ]] [[ range .currencies ]][[ .name ]][[ .symbol ]] [[ end ]]")
+
+	// Simulate CLI arguments with two inline YAML sources and '--set' values with dotted and indexed paths.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{
+		"./test-dry-run-project", "-o", "test-dry-run-output",
+		"-v", "{ app_name: First, dark_mode: false, currencies: [{ name: USD, symbol: $, description: Dollar }] }",
+		"-v", "{ app_name: Second }",
+		"--set", "dark_mode=true,currencies[1].name=EUR",
+		"--set", "currencies[1].symbol=€,currencies[1].description=Euro",
+		"--set-string", "app_name=123",
+	})
+
+	// Run the "dry-run" command.
+	err := testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that the variables were merged in the expected order.
+	content, err := os.ReadFile(filepath.Join("test-dry-run-output", "dummy.txt"))
+	assert.NoError(err)
+	assert.Equal("123 true USD$ EUR€ ", string(content))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
+// TestDryRunCommandWithInvalidVariables tests the "dry-run" command when the variables file
+// does not exist or has a syntax error. It should return an error instead of ignoring the variables.
+func TestDryRunCommandWithInvalidVariables(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-dry-run-project")
+
+	// Create a variables file with a syntax error.
+	err := os.WriteFile("test-variables.yaml", []byte("app_name: Test\ndark_mode: [true\n"), os.ModePerm)
+	assert.NoError(err)
+
+	// Simulate CLI arguments with a variables file that does not exist.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "does-not-exist.yaml"})
+
+	// Assert that the "dry-run" command returned an error.
+	err = testDryRunCommand.Execute()
+	assert.ErrorContains(err, "does not exist")
+
+	// Simulate CLI arguments with the variables file with a syntax error.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "test-variables.yaml"})
+
+	// Assert that the "dry-run" command returned an error with the line of the syntax error.
+	err = testDryRunCommand.Execute()
+	assert.ErrorContains(err, "yaml: line")
+
+	// Simulate CLI arguments with a list index that is too large.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "--set", "currencies[1000000000].name=USD"})
+
+	// Assert that the "dry-run" command returned an error instead of allocating the list.
+	err = testDryRunCommand.Execute()
+	assert.ErrorContains(err, "list indexes cannot be greater than")
+
+	// Simulate CLI arguments with a list index that does not fit in an integer.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "--set", "currencies[99999999999999999999].name=USD"})
+
+	// Assert that the "dry-run" command returned an error.
+	err = testDryRunCommand.Execute()
+	assert.ErrorContains(err, "invalid variable path")
+
+	// Assert that the output directory was not created.
+	assert.NoDirExists("test-dry-run-output")
+
+	// Delete the created files and directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.Remove("test-variables.yaml")
+}
+
+// TestDryRunCommandRedactsSecretVariables tests the "dry-run" command when secret variables
+// are defined. Their values should never be printed to the terminal, and text equal to a short secret should be kept.
+func TestDryRunCommandRedactsSecretVariables(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file with secret variables in the test project directory.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+variables:
+  - name: api_token
+    description: The API token.
+    example: my-token
+    secret: true
+  - name: private_key
+    description: The private key.
+    example: my-key
+    secret: true
+  - name: branch
+    description: The deployment branch.
+    example: develop
+    secret: true
+`
+	err := os.MkdirAll("test-dry-run-project", os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+
+	// Create a file referencing the secret variables, and text equal to the short secret.
+	writeDummyTemplateFile(assert, "test-dry-run-project", "dummy.txt", "token: [[ .api_token ]]\nkey: [[ .private_key ]]\nbranch: [[ .branch ]]\nremote: main")
+
+	// Create a multi-line private key file.
+	privateKeyPath := filepath.Join(t.TempDir(), "key.pem")
+	err = os.WriteFile(privateKeyPath, []byte("-----BEGIN KEY-----\nc2VjcmV0LWtleQ==\n-----END KEY-----\n"), os.ModePerm)
+	assert.NoError(err)
+
+	// Capture the terminal output.
+	var outputBuffer bytes.Buffer
+	terminal.SetTestMode(&outputBuffer)
+	defer terminal.SetTestMode(nil)
+
+	// Simulate CLI arguments to print the filled template files and the variables in the terminal.
+	arguments := []string{"--set", "api_token=super-secret-value,branch=main", "--set-file", "private_key=" + privateKeyPath}
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs(append([]string{"./test-dry-run-project", "-i"}, arguments...))
+	err = testDryRunCommand.Execute()
+	assert.Nil(err)
+
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs(append([]string{"./test-dry-run-project", "--print-variables"}, arguments...))
+	err = testDryRunCommand.Execute()
+	assert.Nil(err)
+
+	// Assert that the secret values were redacted, including each line of the private key.
+	assert.NotContains(outputBuffer.String(), "super-secret-value")
+	assert.NotContains(outputBuffer.String(), "c2VjcmV0LWtleQ==")
+	assert.Contains(outputBuffer.String(), "token: ********")
+	assert.Contains(outputBuffer.String(), "key: ********")
+	assert.Contains(outputBuffer.String(), "api_token: '********'")
+	assert.Contains(outputBuffer.String(), "private_key: '********'")
+
+	// Assert that the short secret was redacted, but not the text that is equal to it.
+	assert.Contains(outputBuffer.String(), "branch: ********")
+	assert.Contains(outputBuffer.String(), "remote: main")
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-dry-run-project")
+}
+
+// TestDryRunCommandRedactsSecretVariablesInJSON tests the "dry-run" command with secret variables in the 'json' output format.
+// The secret values should be redacted before being formatted, so that escaped characters are redacted too,
+// and a secret equal to a key of the result does not corrupt it.
+func TestDryRunCommandRedactsSecretVariablesInJSON(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file with secret variables in the test project directory.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+variables:
+  - name: api_token
+    example: my-token
+    secret: true
+  - name: field
+    example: my-field
+    secret: true
+  - name: app_name
+    example: MyApp
+`
+	err := os.MkdirAll("test-dry-run-project", os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+	writeDummyTemplateFile(assert, "test-dry-run-project", "dummy.txt", "token: [[ .api_token ]]\nfield: [[ .field ]]\napp: [[ .app_name ]]")
+
+	// Create a secret file containing characters escaped in JSON.
+	tokenPath := filepath.Join(t.TempDir(), "token.txt")
+	err = os.WriteFile(tokenPath, []byte("super&secret\n<value>"), os.ModePerm)
+	assert.NoError(err)
+	arguments := []string{"--set-file", "api_token=" + tokenPath, "--set", "field=content,app_name=MyApp", "--output-format", "json"}
+
+	// Print the variables.
+	var outputBuffer bytes.Buffer
+	terminal.SetTestMode(&outputBuffer)
+	defer terminal.SetTestMode(nil)
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs(append([]string{"./test-dry-run-project", "--print-variables"}, arguments...))
+	err = testDryRunCommand.Execute()
+	assert.Nil(err)
+
+	var variables map[string]interface{}
+	assert.NoError(json.Unmarshal(outputBuffer.Bytes(), &variables))
+	assert.Equal(map[string]interface{}{"api_token": "********", "field": "********", "app_name": "MyApp"}, variables)
+
+	// Print the filled template files.
+	outputBuffer.Reset()
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs(append([]string{"./test-dry-run-project", "-i"}, arguments...))
+	err = testDryRunCommand.Execute()
+	assert.Nil(err)
+
+	var result generationResult
+	assert.NoError(json.Unmarshal(outputBuffer.Bytes(), &result))
+	if assert.Len(result.Files, 1) {
+		assert.Equal("dummy.txt", result.Files[0].Path)
+		assert.Equal("token: ********\nfield: ********\napp: MyApp", result.Files[0].Content)
+	}
+	assert.NotContains(outputBuffer.String(), "secret")
+
+	// Delete the created directory after the test.
+	ResetDryRunFlags(testDryRunCommand)
+	os.RemoveAll("test-dry-run-project")
+}
+
+// TestDryRunCommandCopiesBinaryAndCopyOnlyFiles tests the "dry-run" command when the template contains
+// binary files and files matching the 'copy_only' patterns. They should be copied byte for byte.
+func TestDryRunCommandCopiesBinaryAndCopyOnlyFiles(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file with 'copy_only' patterns in the test project directory.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+configuration:
+  copy_only:
+    - "*.jinja"
+`
+	err := os.MkdirAll(filepath.Join("test-dry-run-project", "assets"), os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+
+	// Create a binary file and a copy-only text file, both containing invalid template actions.
+	binaryContent := append([]byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x00, 0x00}, []byte(withDefaultDelimiters("[[ invalid"))...)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", "assets", "logo.png"), binaryContent, os.ModePerm)
+	assert.NoError(err)
+	jinjaContent := "[[ name ]] {% if x %}"
+	writeDummyTemplateFile(assert, "test-dry-run-project", "page.jinja", jinjaContent)
+
+	// Simulate CLI arguments to specify the project directory and the output directory.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "{}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that the files were copied byte for byte.
+	content, err := os.ReadFile(filepath.Join("test-dry-run-output", "assets", "logo.png"))
+	assert.NoError(err)
+	assert.Equal(binaryContent, content)
+	content, err = os.ReadFile(filepath.Join("test-dry-run-output", "page.jinja"))
+	assert.NoError(err)
+	assert.Equal(withDefaultDelimiters(jinjaContent), string(content))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
+// TestDryRunCommandPreservesModesAndSymlinks tests the "dry-run" command when the template contains
+// executable files and symbolic links, and when the metadata file overrides file modes.
+func TestDryRunCommandPreservesModesAndSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes and symbolic links are not fully supported on Windows")
+	}
+
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file with mode overrides in the test project directory.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+configuration:
+  modes:
+    "scripts/*.sh": "0700"
+`
+	err := os.MkdirAll(filepath.Join("test-dry-run-project", "scripts"), os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+
+	// Create an executable file, a file matching the mode override, and a symbolic link.
+	err = os.WriteFile(filepath.Join("test-dry-run-project", "run.sh"), []byte("echo run"), 0755)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", "scripts", "build.sh"), []byte("echo build"), 0644)
+	assert.NoError(err)
+	err = os.Symlink("run.sh", filepath.Join("test-dry-run-project", "start.sh"))
+	assert.NoError(err)
+
+	// Simulate CLI arguments to specify the project directory and the output directory.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "{}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that the executable bit was preserved and the mode override was applied.
+	info, err := os.Stat(filepath.Join("test-dry-run-output", "run.sh"))
+	assert.NoError(err)
+	assert.NotZero(info.Mode().Perm() & 0100)
+	info, err = os.Stat(filepath.Join("test-dry-run-output", "scripts", "build.sh"))
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0700), info.Mode().Perm())
+
+	// Assert that the symbolic link was reproduced as a symbolic link.
+	target, err := os.Readlink(filepath.Join("test-dry-run-output", "start.sh"))
+	assert.NoError(err)
+	assert.Equal("run.sh", target)
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
+// TestDryRunCommandWithSymlinkOutsideTemplate tests the "dry-run" command when the template contains
+// a symbolic link pointing outside the template directory, which must be rejected.
+func TestDryRunCommandWithSymlinkOutsideTemplate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not fully supported on Windows")
+	}
+
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file and a symbolic link pointing outside the template directory.
+	CreateDummyCloneyMetadataFile(assert, "test-dry-run-project")
+	err := os.Symlink(filepath.Join("..", "..", "outside.txt"), filepath.Join("test-dry-run-project", "outside.txt"))
+	assert.NoError(err)
+
+	// Simulate CLI arguments to specify the project directory and the output directory.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "{app_name: x, dark_mode: true, currencies: []}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command returned an error.
+	assert.Error(err)
+	assert.Contains(err.Error(), "points outside the template directory")
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
+// TestDryRunCommandWithIgnorePatterns tests the "dry-run" command with 'ignore_paths' and a '.cloneyignore' file,
+// which follow the '.gitignore' semantics.
+func TestDryRunCommandWithIgnorePatterns(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file with ignore patterns in the test project directory.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+configuration:
+  ignore_paths:
+    - build/
+    - "**/*.log"
+`
+	err := os.MkdirAll("test-dry-run-project", os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+
+	// Create a '.cloneyignore' file with comments, an anchored pattern and a negation.
+	rawIgnoreFile := `
+# Local notes.
+/notes.txt
+!keep.log
+`
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.IgnoreFileName), []byte(rawIgnoreFile), os.ModePerm)
+	assert.NoError(err)
+
+	// Create the template files.
+	for _, filePath := range []string{
+		filepath.Join("build", "out.txt"),
+		filepath.Join("my-build-tools", "tool.txt"),
+		filepath.Join("logs", "deep", "debug.log"),
+		filepath.Join("logs", "keep.log"),
+		"notes.txt",
+		filepath.Join("docs", "notes.txt"),
+	} {
+		err = os.MkdirAll(filepath.Dir(filepath.Join("test-dry-run-project", filePath)), os.ModePerm)
+		assert.NoError(err)
+		err = os.WriteFile(filepath.Join("test-dry-run-project", filePath), []byte("content"), os.ModePerm)
+		assert.NoError(err)
+	}
+
+	// Simulate CLI arguments to specify the project directory and the output directory.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "{}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that only the ignored paths were excluded from the output.
+	for filePath, shouldExist := range map[string]bool{
+		filepath.Join("build", "out.txt"):           false,
+		filepath.Join("my-build-tools", "tool.txt"): true,
+		filepath.Join("logs", "deep", "debug.log"):  false,
+		filepath.Join("logs", "keep.log"):           true,
+		"notes.txt":                                 false,
+		filepath.Join("docs", "notes.txt"):          true,
+		appConfig.IgnoreFileName:                    false,
+	} {
+		_, err = os.Stat(filepath.Join("test-dry-run-output", filePath))
+		assert.Equal(shouldExist, err == nil, filePath)
+	}
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
+// TestDryRunCommandWithCustomDelimiters tests the "dry-run" command with custom action delimiters,
+// both for the whole template and for the files matching a pattern.
+func TestDryRunCommandWithCustomDelimiters(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file with custom delimiters in the test project directory.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+configuration:
+  delimiters: ["<<", ">>"]
+  delimiter_overrides:
+    "*.jinja": ["<%", "%>"]
+variables:
+  - name: app_name
+    example: MyApp
+`
+	err := os.MkdirAll("test-dry-run-project", os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+
+	// Create a workflow file, which uses the default delimiters natively, and a Jinja file.
+	writeDummyTemplateFile(assert, filepath.Join("test-dry-run-project", ".github"), "ci.yaml", "name: << .app_name >>\nrun: echo ${[[ secrets.TOKEN ]]}\n<<- toFile \"generated.txt\" \"nested\" . >>\n<<- define \"nested\" >>generated << .app_name >><< end >>")
+	writeDummyTemplateFile(assert, "test-dry-run-project", "page.jinja", "<% .app_name %> [[ title ]] << raw >>")
+
+	// Simulate CLI arguments to specify the project directory and the output directory.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "{app_name: MyApp}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that only the custom delimiters were rendered.
+	content, err := os.ReadFile(filepath.Join("test-dry-run-output", ".github", "ci.yaml"))
+	assert.NoError(err)
+	assert.Equal(withDefaultDelimiters("name: MyApp\nrun: echo ${[[ secrets.TOKEN ]]}"), string(content))
+	content, err = os.ReadFile(filepath.Join("test-dry-run-output", ".github", "generated.txt"))
+	assert.NoError(err)
+	assert.Equal("generated MyApp", string(content))
+	content, err = os.ReadFile(filepath.Join("test-dry-run-output", "page.jinja"))
+	assert.NoError(err)
+	assert.Equal(withDefaultDelimiters("MyApp [[ title ]] << raw >>"), string(content))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
+// TestDryRunCommandWithToFileInEveryPosition tests the "dry-run" command when 'toFile' is called
+// without spaces, inside a pipeline, in a multi-line action and after a comment mentioning it.
+func TestDryRunCommandWithToFileInEveryPosition(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file and a file calling 'toFile' in different positions.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+`
+	err := os.MkdirAll(filepath.Join("test-dry-run-project", "sub"), os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+	writeDummyTemplateFile(assert, filepath.Join("test-dry-run-project", "sub"), "main.txt", `[[- /* toFile */ -]]
+[[toFile "a.txt" "content" .]]
+[[ . | toFile "b.txt" "content" ]]
+[[ toFile
+  "../c.txt"
+  "content" . ]]
+[[- define "content" ]]generated[[ end ]]`)
+
+	// Simulate CLI arguments to specify the project directory and the output directory.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "{}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that every file was created relative to the directory of the file calling 'toFile'.
+	for _, filePath := range []string{
+		filepath.Join("sub", "a.txt"),
+		filepath.Join("sub", "b.txt"),
+		"c.txt",
+	} {
+		content, err := os.ReadFile(filepath.Join("test-dry-run-output", filePath))
+		assert.NoError(err)
+		assert.Equal("generated", string(content))
+	}
+
+	// Simulate CLI arguments to output the result in the terminal, where 'toFile' creates virtual files.
+	var output bytes.Buffer
+	terminal.SetTestMode(&output)
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-i", "-v", "{}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+	terminal.SetTestMode(nil)
+
+	// Assert that the "dry-run" command did not return an error and reported the virtual files.
+	assert.Nil(err)
+	assert.Contains(output.String(), "[created with 'toFile']")
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
 // TODO: Add remaining tests...
+
+// TestDryRunCommandWithPartialsDirectory tests the "dry-run" command when the template has a partials directory.
+// Its 'define' blocks can be included by every file, the files can override them, and the directory is not generated.
+func TestDryRunCommandWithPartialsDirectory(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a Cloney metadata file in the test project directory.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+variables:
+  - name: app_name
+    example: MyApp
+`
+	err := os.MkdirAll("test-dry-run-project", os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+
+	// Create a partial file with two 'define' blocks, and a file that includes one and overrides the other.
+	writeDummyTemplateFile(assert, filepath.Join("test-dry-run-project", ".cloney", "partials"), "common.txt", "[[ define \"header\" ]]# [[ .app_name ]][[ end ]]\n[[ define \"footer\" ]]default footer[[ end ]]")
+	writeDummyTemplateFile(assert, "test-dry-run-project", "README.md", "[[ include \"header\" . ]]\n[[ include \"footer\" . ]][[ define \"footer\" ]]custom footer[[ end ]]")
+
+	// Simulate CLI arguments to specify the project directory and the output directory.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "{app_name: MyApp}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that the partials were included and overridden, and that the partials directory was not generated.
+	content, err := os.ReadFile(filepath.Join("test-dry-run-output", "README.md"))
+	assert.NoError(err)
+	assert.Equal("# MyApp\ncustom footer", string(content))
+	_, err = os.Stat(filepath.Join("test-dry-run-output", ".cloney"))
+	assert.True(os.IsNotExist(err))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
+// TestDryRunCommandWithBaseTemplate tests the "dry-run" command when the template extends a local base template.
+// The files and variables of the base template are inherited, and the template overrides files, variables and blocks.
+func TestDryRunCommandWithBaseTemplate(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a base template with two variables, a file using a block and a file overridden by the template.
+	baseMetadata := `
+manifest_version: v1
+name: Base
+template_version: 0.0.0
+configuration:
+  ignore_paths: ["notes.txt"]
+variables:
+  - name: app_name
+    example: MyApp
+  - name: license
+    default: Apache-2.0
+    example: MIT
+`
+	err := os.MkdirAll("test-dry-run-base", os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-base", appConfig.MetadataFileName), []byte(baseMetadata), os.ModePerm)
+	assert.NoError(err)
+
+	writeDummyTemplateFile(assert, "test-dry-run-base", "ci.yaml", "app: [[ .app_name ]]\n[[ block \"steps\" . ]]default steps[[ end ]]")
+	writeDummyTemplateFile(assert, "test-dry-run-base", "LICENSE", "[[ .license ]]")
+	err = os.WriteFile(filepath.Join("test-dry-run-base", "README.md"), []byte("base readme"), os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-base", "notes.txt"), []byte("base notes"), os.ModePerm)
+	assert.NoError(err)
+
+	// Create a template that extends the base template, overriding a variable, a file and the block.
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+extends:
+  source: ../test-dry-run-base
+variables:
+  - name: license
+    default: MIT
+    example: MIT
+`
+	err = os.MkdirAll("test-dry-run-project", os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-dry-run-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+	writeDummyTemplateFile(assert, "test-dry-run-project", "README.md", "# [[ .app_name ]]")
+	writeDummyTemplateFile(assert, "test-dry-run-project", "__steps.txt", "[[ define \"steps\" ]]custom steps[[ end ]]")
+
+	// Simulate CLI arguments to specify the project directory and the output directory.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "-v", "{app_name: MyApp}"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that the files were inherited or overridden, and that the block was overridden.
+	content, err := os.ReadFile(filepath.Join("test-dry-run-output", "ci.yaml"))
+	assert.NoError(err)
+	assert.Equal("app: MyApp\ncustom steps", string(content))
+	content, err = os.ReadFile(filepath.Join("test-dry-run-output", "LICENSE"))
+	assert.NoError(err)
+	assert.Equal("MIT", string(content))
+	content, err = os.ReadFile(filepath.Join("test-dry-run-output", "README.md"))
+	assert.NoError(err)
+	assert.Equal("# MyApp", string(content))
+	_, err = os.Stat(filepath.Join("test-dry-run-output", "notes.txt"))
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join("test-dry-run-output", appConfig.MetadataFileName))
+	assert.True(os.IsNotExist(err))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-base")
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
+
+// TestDryRunCommandWithJSONOutputFormat tests the "dry-run" command with the '--output-in-terminal' flag
+// and the 'json' output format. It should print the rendered files with their contents, without writing them.
+func TestDryRunCommandWithJSONOutputFormat(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file, a template file and an ignored file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-dry-run-project")
+	writeDummyTemplateFile(assert, "test-dry-run-project", "dummy.txt", "[[ .app_name ]]")
+	writeDummyTemplateFile(assert, "test-dry-run-project", "__ignored.txt", "Not generated.")
+
+	// Redirect stdout to a buffer.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Simulate CLI arguments to specify the project directory, the variables and the output format.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-i", "-v", "{ app_name: JSONProject, dark_mode: true, currencies: [] }", "--output-format", "json"})
+
+	// Run the "dry-run" command.
+	err := testDryRunCommand.Execute()
+	ResetDryRunFlags(testDryRunCommand)
+
+	// Assert that the "dry-run" command did not return an error and only printed the rendered files as JSON.
+	assert.Nil(err)
+	var result struct {
+		Created []string
+		Files   []generatedFile
+	}
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &result))
+	assert.Equal([]string{"dummy.txt"}, result.Created)
+	assert.Len(result.Files, 1)
+	assert.Equal(generatedFile{Path: "dummy.txt", Content: "JSONProject"}, result.Files[0])
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-dry-run-project")
+}
+
+// TestDryRunCommandWithConfigurationVariables tests the "dry-run" command when the user configuration file
+// defines default variables. They should be used, unless another source defines the same variables.
+func TestDryRunCommandWithConfigurationVariables(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file and a file referencing the variables in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-dry-run-project")
+	writeDummyTemplateFile(assert, "test-dry-run-project", "dummy.txt", "[[ .app_name ]] [[ .dark_mode ]]")
+
+	// Create a configuration file with default variables.
+	configFilePath := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFilePath, []byte("variables:\n  app_name: ConfigProject\n  dark_mode: false\n  currencies: []\n"), os.ModePerm)
+	assert.NoError(err)
+	t.Setenv("CLONEY_CONFIG", configFilePath)
+
+	// Simulate CLI arguments to specify the project directory, the output directory and a variable.
+	ResetDryRunFlags(testDryRunCommand)
+	testDryRunCommand.SetArgs([]string{"./test-dry-run-project", "-o", "test-dry-run-output", "--set", "dark_mode=true"})
+
+	// Run the "dry-run" command.
+	err = testDryRunCommand.Execute()
+
+	// Assert that the "dry-run" command did not return an error and used the variables of the configuration file.
+	assert.Nil(err)
+	content, err := os.ReadFile(filepath.Join("test-dry-run-output", "dummy.txt"))
+	assert.NoError(err)
+	assert.Equal("ConfigProject true", string(content))
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-dry-run-project")
+	os.RemoveAll("test-dry-run-output")
+}
@@ -1,13 +1,9 @@
 package commands
 
 import (
-	"fmt"
-	"path/filepath"
 	"strings"
 
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/cli/commands/steps"
-	"github.com/ArthurSudbrackIbarra/cloney/pkg/config"
-	"github.com/ArthurSudbrackIbarra/cloney/pkg/git"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
 
 	"github.com/spf13/cobra"
@@ -18,52 +14,23 @@ func infoCmdRun(cmd *cobra.Command, args []string) error {
 	// Get command-line arguments.
 	var repositorySource string
 	if len(args) >= 1 {
-		repositorySource = args[0]
+		repositorySource = steps.ResolveAlias(args[0])
 	}
-	branch, _ := cmd.Flags().GetString("branch")
-	tag, _ := cmd.Flags().GetString("tag")
+	branch, tag := getBranchAndTag(cmd)
 	token, _ := cmd.Flags().GetString("token")
 
-	// Variable to store errors.
-	var err error
-
-	// Suppress prints for this command.
-	steps.SetSuppressPrints(true)
-
-	// Variable to store the metadata file content.
-	var metadataContent string
-
-	// If the argument is a git repository URL, use it.
-	if git.MatchesGitRepositoryURL(repositorySource) {
-		// Create and validate the git repository.
-		repository, err := steps.CreateAndValidateRepository(repositorySource, branch, tag)
-		if err != nil {
-			return err
-		}
-
-		// If a token is provided, authenticate with it.
-		steps.AuthenticateToRepository(repository, token)
-
-		// Get the metadata file content.
-		metadataContent, err = repository.GetFileContent(appConfig.MetadataFileName)
-		if err != nil {
-			terminal.ErrorMessage(
-				fmt.Sprintf("Error reading the repository '%s' metadata file:", appConfig.MetadataFileName), err,
-			)
-			return err
-		}
-	} else {
-		// If the argument is not a git repository URL, assume it is a local path.
-
-		// Calculate the directory path.
-		repositorySource, _ := steps.CalculatePath(repositorySource, "")
-
-		// Get the metadata file content.
-		metadataFilePath := filepath.Join(repositorySource, config.GetAppConfig().MetadataFileName)
-		metadataContent, err = steps.ReadRepositoryMetadata(metadataFilePath)
-		if err != nil {
-			return err
-		}
+	// The messages of the steps are only printed in the verbose and debug log levels.
+	restoreLogLevel := terminal.LowerLogLevel()
+	defer restoreLogLevel()
+
+	// Stop fetching when the command is interrupted or times out.
+	ctx, cancel := commandContext(cmd)
+	defer cancel()
+
+	// Get the metadata file content, either from a remote or a local template repository.
+	metadataContent, err := steps.GetRepositoryMetadataContent(ctx, repositorySource, branch, tag, token)
+	if err != nil {
+		return err
 	}
 
 	// Create the metadata struct from raw YAML data.
@@ -72,7 +39,20 @@ func infoCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Find or fetch the base templates, and merge their metadata into the template repository metadata,
+	// so that the effective variables are shown.
+	cloneyMetadata, _, cleanupBases, err := steps.ResolveExtends(ctx, steps.GetLocalRepositoryPath(repositorySource), cloneyMetadata, token)
+	if err != nil {
+		return err
+	}
+	cleanupBases()
+	restoreLogLevel()
+
 	// Print metadata.
+	// In the 'json' and 'yaml' output formats, the variable types are given separately from their examples.
+	if terminal.IsStructuredOutput() {
+		return printResult(cloneyMetadata.Summary())
+	}
 	terminal.Message(cloneyMetadata.String())
 
 	return nil
@@ -81,8 +61,12 @@ func infoCmdRun(cmd *cobra.Command, args []string) error {
 // ResetInfoCommandFlags resets the flags of the 'info' command.
 func ResetInfoCommandFlags(cmd *cobra.Command) {
 	cmd.Flags().Set("branch", "main")
+	// The default branch of the configuration is only used if the flag was not set.
+	cmd.Flags().Lookup("branch").Changed = false
 	cmd.Flags().Set("tag", "")
 	cmd.Flags().Set("token", "")
+	cmd.Flags().Set("timeout", "0s")
+	resetOutputFormatFlag(cmd)
 }
 
 // CreateInfoCommand creates the 'info' command and its respective flags.
@@ -98,21 +82,25 @@ The 'cloney info' command will give you information about a Cloney template repo
 
 It can get information from a local template repository, or from a remote template repository.
 By default, it will get information from the current directory, assuming it is a template repository.
+Template repositories can also be referenced by the aliases defined in the configuration files.
 `,
 		Example: strings.Join([]string{
 			"  info",
 			"  info ./path/to/my/template",
 			"  info https://github.com/username/repository.git",
+			"  info my-alias",
 		}, "\n"),
-		Aliases:          []string{"more"},
-		PersistentPreRun: persistentPreRun,
-		RunE:             infoCmdRun,
+		Aliases:           []string{"more"},
+		PersistentPreRunE: persistentPreRun,
+		RunE:              infoCmdRun,
 	}
 
 	// Define command-line flags for the 'info' command.
-	infoCmd.Flags().StringP("branch", "b", "main", "Git branch, if referencing a git repository")
+	infoCmd.Flags().StringP("branch", "b", "main", "Git branch, if referencing a git repository and no tag is given (defaults to the 'default_branch' of the configuration)")
 	infoCmd.Flags().StringP("tag", "t", "", "Git tag, if referencing a git repository")
 	infoCmd.Flags().StringP("token", "k", "", "Git token, if referencing a private git repository (not recommended)")
+	addTimeoutFlag(infoCmd)
+	setHasResult(infoCmd)
 
 	return infoCmd
 }
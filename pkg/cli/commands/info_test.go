@@ -1,15 +1,19 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
 	"github.com/stretchr/testify/assert"
 )
 
 // testInfoCommand represents a command instance used for testing.
-var testInfoCommand = CreateInfoCommand()
+var testInfoCommand = newTestCommand(CreateInfoCommand())
 
 // CreateDummyCloneyMetadataFile creates a dummy Cloney metadata file in the specified directory.
 func CreateDummyCloneyMetadataFile(assert *assert.Assertions, directory string) {
@@ -136,3 +140,129 @@ func TestInfoCommandPointingToInvalidRemoteCloneyProject(t *testing.T) {
 	// Assert that the "info" command returned an error.
 	assert.NotNil(err)
 }
+
+// TestInfoCommandPointingToCloneyProjectWithBaseTemplate tests the "info" command
+// when the template extends a local base template. It should print the variables of both templates.
+func TestInfoCommandPointingToCloneyProjectWithBaseTemplate(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a base template with the dummy metadata file, and a template extending it with one more variable.
+	CreateDummyCloneyMetadataFile(assert, "test-base-project")
+	rawMetadata := `
+manifest_version: v1
+name: TestProject
+template_version: 0.0.0
+extends:
+  source: ../test-base-project
+variables:
+  - name: team_name
+    example: Platform
+`
+	err := os.MkdirAll("test-project", os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join("test-project", appConfig.MetadataFileName), []byte(rawMetadata), os.ModePerm)
+	assert.NoError(err)
+
+	// Redirect stdout to a buffer.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Simulate CLI arguments with flags and values to specify the project directory.
+	ResetInfoCommandFlags(testInfoCommand)
+	testInfoCommand.SetArgs([]string{"./test-project"})
+
+	// Execute the "info" command.
+	err = testInfoCommand.Execute()
+
+	// Assert that the "info" command did not return an error and printed the inherited variables.
+	assert.Nil(err)
+	assert.Contains(buffer.String(), "../test-base-project")
+	assert.Contains(buffer.String(), "app_name")
+	assert.Contains(buffer.String(), "currencies")
+	assert.Contains(buffer.String(), "team_name")
+
+	// Delete the created directories after the test.
+	os.RemoveAll("test-base-project")
+	os.RemoveAll("test-project")
+}
+
+// TestInfoCommandWithJSONOutputFormat tests the "info" command with the 'json' output format.
+// It should only print the metadata as JSON, with the type of each variable.
+func TestInfoCommandWithJSONOutputFormat(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-project")
+
+	// Redirect stdout to a buffer.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Simulate CLI arguments with flags and values to specify the project directory and the output format.
+	ResetInfoCommandFlags(testInfoCommand)
+	testInfoCommand.SetArgs([]string{"./test-project", "--output-format", "json"})
+
+	// Execute the "info" command.
+	err := testInfoCommand.Execute()
+	ResetInfoCommandFlags(testInfoCommand)
+
+	// Assert that the "info" command did not return an error and printed valid JSON.
+	assert.Nil(err)
+	var summary struct {
+		Name      string
+		Variables []struct {
+			Name     string
+			Type     string
+			Required bool
+		}
+	}
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &summary))
+	assert.Equal("TestProject", summary.Name)
+	assert.Len(summary.Variables, 3)
+	assert.Equal("string", summary.Variables[0].Type)
+	assert.Equal("boolean", summary.Variables[1].Type)
+	assert.Equal("list", summary.Variables[2].Type)
+	assert.True(summary.Variables[0].Required)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-project")
+}
+
+// TestInfoCommandWithConfigurationAlias tests the "info" command when the template repository is referenced
+// by an alias of the user configuration file. It should print the information of the aliased template.
+func TestInfoCommandWithConfigurationAlias(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory, and a configuration file with an alias to it.
+	CreateDummyCloneyMetadataFile(assert, "test-project")
+	absolutePath, err := filepath.Abs("test-project")
+	assert.NoError(err)
+	configFilePath := filepath.Join(t.TempDir(), "config.yaml")
+	err = os.WriteFile(configFilePath, []byte("aliases:\n  my-template: "+absolutePath+"\n"), os.ModePerm)
+	assert.NoError(err)
+	t.Setenv("CLONEY_CONFIG", configFilePath)
+
+	// Redirect stdout to a buffer.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Simulate CLI arguments with the alias.
+	ResetInfoCommandFlags(testInfoCommand)
+	testInfoCommand.SetArgs([]string{"my-template"})
+
+	// Execute the "info" command.
+	err = testInfoCommand.Execute()
+
+	// Assert that the "info" command did not return an error and printed the aliased template.
+	assert.Nil(err)
+	assert.Contains(buffer.String(), "TestProject")
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-project")
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/cli/commands/steps"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+// lintCmdRun is the function that runs when the 'lint' command is called.
+func lintCmdRun(cmd *cobra.Command, args []string) error {
+	// Get command-line arguments.
+	var repositorySource string
+	if len(args) >= 1 {
+		repositorySource = args[0]
+	}
+
+	// Stop fetching when the command is interrupted.
+	ctx, cancel := commandContext(cmd)
+	defer cancel()
+
+	// Variable to store errors.
+	var err error
+
+	// Calculate the template directory path.
+	sourcePath, err := steps.CalculatePath(repositorySource, "")
+	if err != nil {
+		return err
+	}
+
+	// Read the repository metadata file.
+	metadataFilePath := filepath.Join(sourcePath, appConfig.MetadataFileName)
+	metadataContent, err := steps.ReadRepositoryMetadata(metadataFilePath)
+	if err != nil {
+		return err
+	}
+
+	// Parse the metadata file.
+	cloneyMetadata, err := steps.ParseRepositoryMetadata(metadataContent, appConfig.SupportedManifestVersions)
+	if err != nil {
+		return err
+	}
+
+	// Find or fetch the base templates, and merge their metadata into the template repository metadata.
+	cloneyMetadata, bases, cleanupBases, err := steps.ResolveExtends(ctx, sourcePath, cloneyMetadata, "")
+	if err != nil {
+		return err
+	}
+	defer cleanupBases()
+
+	// Define options for ignoring specific files and directories, the same way as when cloning.
+	ignorePaths, err := steps.GetIgnorePaths(sourcePath, cloneyMetadata)
+	if err != nil {
+		return err
+	}
+
+	// Fetch the partial libraries and find the partials directory.
+	partials, cleanupPartials, err := steps.GetPartials(ctx, sourcePath, cloneyMetadata, bases, "")
+	if err != nil {
+		return err
+	}
+	defer cleanupPartials()
+
+	// Lint the template files.
+	issues, err := steps.LintDirectory(sourcePath, ignorePaths, cloneyMetadata, partials, bases)
+	if err != nil {
+		return err
+	}
+
+	// Print the issues found, with paths relative to the template directory.
+	// In the 'json' and 'yaml' output formats, they are printed together as diagnostics.
+	var errorsCount int
+	result := validationResult{Diagnostics: []diagnostic{}}
+	for _, issue := range issues {
+		if relativePath, err := filepath.Rel(sourcePath, issue.File); err == nil && filepath.IsAbs(issue.File) {
+			issue.File = relativePath
+		}
+		result.Diagnostics = append(result.Diagnostics, diagnostic{
+			Severity: issue.Severity,
+			File:     filepath.ToSlash(issue.File),
+			Line:     issue.Line,
+			Message:  issue.Message,
+		})
+		if terminal.IsStructuredOutput() {
+			if issue.Severity == templates.LINT_ERROR_SEVERITY {
+				errorsCount++
+			}
+			continue
+		}
+		if issue.Severity == templates.LINT_ERROR_SEVERITY {
+			errorsCount++
+			terminal.ErrorMessage(issue.String(), nil)
+		} else {
+			terminal.WarningMessage(issue.String())
+		}
+	}
+
+	if terminal.IsStructuredOutput() {
+		result.Valid = errorsCount == 0
+		if err := printResult(result); err != nil {
+			return err
+		}
+	}
+
+	if errorsCount > 0 {
+		return fmt.Errorf("found %d error(s) in the template files", errorsCount)
+	}
+
+	// If no errors were found, then the template files are valid.
+	terminal.Message("\nYour Cloney template files are valid!")
+
+	return nil
+}
+
+// ResetLintCommandFlags resets the flags of the 'lint' command.
+func ResetLintCommandFlags(cmd *cobra.Command) {
+	resetOutputFormatFlag(cmd)
+}
+
+// CreateLintCommand creates the 'lint' command.
+func CreateLintCommand() *cobra.Command {
+	lintCmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Lint the template files of your Cloney template repository",
+		Long: `Lint the template files of your Cloney template repository.
+
+The 'cloney lint' command parses every template file that is not ignored and reports:
+  - Syntax errors, with the file and line where they occur.
+  - References to variables that are not declared in the metadata file.
+  - Variables declared in the metadata file that are never used.
+  - 'include', 'toFile' and 'template' calls to templates that do not exist.
+`,
+		Example: strings.Join([]string{
+			"  lint",
+			"  lint ./path/to/my/template",
+		}, "\n"),
+		PersistentPreRunE: persistentPreRun,
+		RunE:              lintCmdRun,
+	}
+
+	// Define command-line flags for the 'lint' command.
+	setHasResult(lintCmd)
+
+	return lintCmd
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLintCommand represents a command instance used for testing.
+var testLintCommand = newTestCommand(CreateLintCommand())
+
+// withDefaultDelimiters converts the actions of a test template, written with '[[' and ']]', to the default delimiters.
+// The 'dry-run' tests render this package directory and would fail on template actions written literally in test files,
+// so the template files and the expected contents of the tests of this package are written with this function.
+func withDefaultDelimiters(content string) string {
+	return strings.NewReplacer("[[", "{"+"{", "]]", "}"+"}").Replace(content)
+}
+
+// writeDummyTemplateFile writes a template file in the specified directory, converting its actions with
+// 'withDefaultDelimiters'.
+func writeDummyTemplateFile(assert *assert.Assertions, directory, name, content string) {
+	err := os.MkdirAll(directory, os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join(directory, name), []byte(withDefaultDelimiters(content)), os.ModePerm)
+	assert.NoError(err)
+}
+
+// TestLintCommandWhenTemplateIsValid tests the "lint" command
+// when the template files only reference declared variables. It should not return an error.
+func TestLintCommandWhenTemplateIsValid(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file and a dummy txt file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-lint-project")
+	CreateDummyTxtFile(assert, "test-lint-project")
+
+	// Simulate CLI arguments to specify the project directory.
+	testLintCommand.SetArgs([]string{"./test-lint-project"})
+
+	// Execute the "lint" command.
+	err := testLintCommand.Execute()
+
+	// Assert that the "lint" command did not return an error.
+	assert.Nil(err)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-lint-project")
+}
+
+// TestLintCommandWhenTemplateHasUndeclaredVariable tests the "lint" command
+// when a template file references a variable that is not declared. It should return an error.
+func TestLintCommandWhenTemplateHasUndeclaredVariable(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file and a dummy txt file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-lint-project")
+	CreateDummyTxtFile(assert, "test-lint-project")
+
+	// Create a file referencing an undeclared variable.
+	writeDummyTemplateFile(assert, "test-lint-project", "undeclared.txt", "[[ .undeclared_variable ]]")
+
+	// Simulate CLI arguments to specify the project directory.
+	testLintCommand.SetArgs([]string{"./test-lint-project"})
+
+	// Execute the "lint" command.
+	err := testLintCommand.Execute()
+
+	// Assert that the "lint" command returned an error.
+	assert.NotNil(err)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-lint-project")
+}
+
+// TestLintCommandWhenTemplateHasSyntaxError tests the "lint" command
+// when a template file has a syntax error. It should return an error and report the line of the error.
+func TestLintCommandWhenTemplateHasSyntaxError(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file and a dummy txt file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-lint-project")
+	CreateDummyTxtFile(assert, "test-lint-project")
+
+	// Create a file with an unclosed action on its second line.
+	writeDummyTemplateFile(assert, "test-lint-project", "broken.txt", "line 1\n[[ if .dark_mode }")
+
+	// Capture the output of the command.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Simulate CLI arguments to specify the project directory.
+	testLintCommand.SetArgs([]string{"./test-lint-project", "--output-format", "json"})
+
+	// Execute the "lint" command.
+	err := testLintCommand.Execute()
+	ResetLintCommandFlags(testLintCommand)
+
+	// Assert that the "lint" command returned an error and reported the syntax error.
+	assert.NotNil(err)
+	var result validationResult
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &result))
+	assert.False(result.Valid)
+	assert.Len(result.Diagnostics, 1)
+	assert.Equal(templates.LINT_ERROR_SEVERITY, result.Diagnostics[0].Severity)
+	assert.Equal("broken.txt", result.Diagnostics[0].File)
+	assert.Equal(2, result.Diagnostics[0].Line)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-lint-project")
+}
+
+// TestLintCommandWhenTemplateIncludesMissingTemplate tests the "lint" command
+// when a template file includes a template that does not exist. It should return an error and report the line of the call.
+func TestLintCommandWhenTemplateIncludesMissingTemplate(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file and a dummy txt file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-lint-project")
+	CreateDummyTxtFile(assert, "test-lint-project")
+
+	// Create a file including a template that does not exist on its third line.
+	writeDummyTemplateFile(assert, "test-lint-project", "include.txt", "line 1\nline 2\n[[ include \"missing\" . ]]")
+
+	// Capture the output of the command.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Simulate CLI arguments to specify the project directory.
+	testLintCommand.SetArgs([]string{"./test-lint-project", "--output-format", "json"})
+
+	// Execute the "lint" command.
+	err := testLintCommand.Execute()
+	ResetLintCommandFlags(testLintCommand)
+
+	// Assert that the "lint" command returned an error and reported the missing template.
+	assert.NotNil(err)
+	var result validationResult
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &result))
+	assert.False(result.Valid)
+	assert.Len(result.Diagnostics, 1)
+	assert.Equal(templates.LINT_ERROR_SEVERITY, result.Diagnostics[0].Severity)
+	assert.Equal("include.txt", result.Diagnostics[0].File)
+	assert.Equal(3, result.Diagnostics[0].Line)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-lint-project")
+}
+
+// TestLintCommandWhenVariableIsNeverUsed tests the "lint" command
+// when a variable declared in the metadata file is never used. It should report a warning, but not return an error.
+func TestLintCommandWhenVariableIsNeverUsed(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory, and a file using only one of its variables.
+	CreateDummyCloneyMetadataFile(assert, "test-lint-project")
+	writeDummyTemplateFile(assert, "test-lint-project", "README.md", "# [[ .app_name ]]\n[[ .dark_mode ]]")
+
+	// Capture the output of the command.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Simulate CLI arguments to specify the project directory.
+	testLintCommand.SetArgs([]string{"./test-lint-project", "--output-format", "json"})
+
+	// Execute the "lint" command.
+	err := testLintCommand.Execute()
+	ResetLintCommandFlags(testLintCommand)
+
+	// Assert that the "lint" command did not return an error and reported the unused variable.
+	assert.Nil(err)
+	var result validationResult
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &result))
+	assert.True(result.Valid)
+	assert.Len(result.Diagnostics, 1)
+	assert.Equal(templates.LINT_WARNING_SEVERITY, result.Diagnostics[0].Severity)
+	assert.Equal(appConfig.MetadataFileName, result.Diagnostics[0].File)
+	assert.Contains(result.Diagnostics[0].Message, "'currencies'")
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-lint-project")
+}
+
+// TestLintCommandWhenTemplateIncludesPartials tests the "lint" command
+// when a template file includes a template defined in the partials directory. It should not return an error.
+func TestLintCommandWhenTemplateIncludesPartials(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file and a dummy txt file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-lint-project")
+	CreateDummyTxtFile(assert, "test-lint-project")
+
+	// Create a partial file and a file including it.
+	writeDummyTemplateFile(assert, filepath.Join("test-lint-project", ".cloney", "partials"), "common.txt", "[[ define \"license\" ]]MIT[[ end ]]")
+	writeDummyTemplateFile(assert, "test-lint-project", "LICENSE", "[[ include \"license\" . ]]")
+
+	// Simulate CLI arguments to specify the project directory.
+	testLintCommand.SetArgs([]string{"./test-lint-project"})
+
+	// Execute the "lint" command.
+	err := testLintCommand.Execute()
+
+	// Assert that the "lint" command did not return an error.
+	assert.Nil(err)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-lint-project")
+}
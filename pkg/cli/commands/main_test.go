@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestMain runs the command tests with an empty configuration directory, so that the configuration files
+// of the developer running the tests are never loaded. Tests that need a configuration file set 'CLONEY_CONFIG'.
+func TestMain(m *testing.M) {
+	configDir, err := os.MkdirTemp("", "cloney-config-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv("XDG_CONFIG_HOME", configDir)
+	os.Unsetenv("CLONEY_CONFIG")
+
+	code := m.Run()
+	os.RemoveAll(configDir)
+	os.Exit(code)
+}
+
+// newTestCommand defines the global flags on a command created alone, as the root command does for its subcommands.
+func newTestCommand(cmd *cobra.Command) *cobra.Command {
+	AddGlobalFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+// This file defines the results printed by the commands in the 'json' and 'yaml' output formats.
+
+// diagnostic is a problem found in a template repository.
+type diagnostic struct {
+	// Severity is the severity of the problem, either 'error' or 'warning'.
+	Severity string `json:"severity" yaml:"severity"`
+
+	// File is the path of the file where the problem was found, relative to the template repository.
+	File string `json:"file" yaml:"file"`
+
+	// Line is the line of the file where the problem was found, if it is related to a specific line.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+
+	// Message describes the problem.
+	Message string `json:"message" yaml:"message"`
+}
+
+// validationResult is the result of the 'validate' and 'lint' commands.
+type validationResult struct {
+	// Valid is true if no error was found. Warnings do not make a template repository invalid.
+	Valid bool `json:"valid" yaml:"valid"`
+
+	// Diagnostics is the list of problems found.
+	Diagnostics []diagnostic `json:"diagnostics" yaml:"diagnostics"`
+}
+
+// generatedFile is a file rendered by the 'dry-run' command with the '--output-in-terminal' flag.
+type generatedFile struct {
+	// Path is the slash-separated path of the file, relative to the template repository.
+	Path string `json:"path" yaml:"path"`
+
+	// Content is the content of the file. It is empty for symbolic links.
+	Content string `json:"content" yaml:"content"`
+
+	// LinkTarget is the target of the file, if it is a symbolic link.
+	LinkTarget string `json:"link_target,omitempty" yaml:"link_target,omitempty"`
+}
+
+// generationResult is the result of the 'clone' and 'dry-run' commands.
+type generationResult struct {
+	// Output is the directory the files were generated into. It is empty if the files were not written.
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+
+	// MergeReport lists the files written and skipped, relative to the output directory.
+	// Without the '--into' flag of the 'clone' command, every file is created.
+	templates.MergeReport `yaml:",inline"`
+
+	// Files is the list of files rendered in memory, with their contents, if they were not written.
+	Files []generatedFile `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// resultAnnotation is the annotation of the commands that print a result in the 'json' and 'yaml' output formats.
+const resultAnnotation = "cloney_result"
+
+// setHasResult marks a command as printing a result in the 'json' and 'yaml' output formats.
+func setHasResult(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[resultAnnotation] = "true"
+}
+
+// setOutputFormat sets the output format of the terminal from the global '--output-format' flag.
+// Commands that print no result refuse the 'json' and 'yaml' output formats.
+func setOutputFormat(cmd *cobra.Command) error {
+	format, err := cmd.Flags().GetString("output-format")
+	if err != nil {
+		// The flag is not defined when the command is created alone.
+		format = terminal.TEXT_OUTPUT_FORMAT
+	}
+	err = terminal.SetOutputFormat(format)
+	if err == nil && terminal.IsStructuredOutput() && cmd.Annotations[resultAnnotation] == "" {
+		terminal.SetOutputFormat(terminal.TEXT_OUTPUT_FORMAT)
+		err = fmt.Errorf("the '%s' command has no result to print in the '%s' output format", cmd.Name(), format)
+	}
+	if err != nil {
+		terminal.ErrorMessage("Invalid flags", err)
+		return err
+	}
+	return nil
+}
+
+// resetOutputFormatFlag resets the global '--output-format' flag, if the command has it.
+func resetOutputFormatFlag(cmd *cobra.Command) {
+	if flag := cmd.Flag("output-format"); flag != nil {
+		flag.Value.Set(terminal.TEXT_OUTPUT_FORMAT)
+	}
+}
+
+// printResult prints the result of a command in the 'json' or 'yaml' output format.
+func printResult(result interface{}) error {
+	err := terminal.Output(result)
+	if err != nil {
+		terminal.ErrorMessage("Could not print the result", err)
+		return err
+	}
+	return nil
+}
+
+// listGeneratedFiles returns the result of generating files into a directory, listing all its files as created.
+func listGeneratedFiles(directory, outputPath string) (generationResult, error) {
+	files, err := templates.ListFiles(os.DirFS(directory), nil)
+	if err != nil {
+		terminal.ErrorMessage("Could not list the generated files", err)
+		return generationResult{}, err
+	}
+	result := generationResult{Output: outputPath}
+	result.Created = append([]string{}, files...)
+	return result, nil
+}
+
+// listRenderedFiles returns the result of rendering files in memory, listing all files as created, with their contents.
+// Files starting with the Ignore Prefix are not listed, since they are never generated.
+func listRenderedFiles(rendered *templates.MemoryFS) generationResult {
+	result := generationResult{Files: []generatedFile{}}
+	result.Created = []string{}
+	for _, name := range rendered.Names() {
+		if strings.HasPrefix(path.Base(name), appConfig.IgnorePrefix) {
+			continue
+		}
+		result.Created = append(result.Created, name)
+		file := generatedFile{Path: name}
+		if rendered.IsSymlink(name) {
+			file.LinkTarget, _ = rendered.ReadLink(name)
+		} else {
+			content, _ := rendered.ReadFile(name)
+			file.Content = string(content)
+		}
+		result.Files = append(result.Files, file)
+	}
+	return result
+}
@@ -1,10 +1,17 @@
 package commands
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/cli/commands/steps"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/config"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // appConfig stores the application configuration.
@@ -14,6 +21,133 @@ var appConfig = config.GetAppConfig()
 // persistentPreRun is executed before every command.
 // It sets the current command, allowing messages to be printed to the command's output using cmd.Print().
 // This is essential for capturing the output of the command to be used in the tests.
-func persistentPreRun(cmd *cobra.Command, args []string) {
+// It also loads the configuration files, returning an error if they are invalid.
+func persistentPreRun(cmd *cobra.Command, args []string) error {
 	terminal.SetCmd(cmd)
+
+	// Set the log level, the colors and the output format from the global flags.
+	// The flags are not defined when the commands are created alone, without the root command.
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	debug, _ := cmd.Flags().GetBool("debug")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	switch {
+	case debug:
+		terminal.SetLogLevel(terminal.DEBUG_LOG_LEVEL)
+	case verbose:
+		terminal.SetLogLevel(terminal.VERBOSE_LOG_LEVEL)
+	case quiet:
+		terminal.SetLogLevel(terminal.QUIET_LOG_LEVEL)
+	default:
+		terminal.SetLogLevel(terminal.NORMAL_LOG_LEVEL)
+	}
+	terminal.SetColors(!noColor)
+	if err := setOutputFormat(cmd); err != nil {
+		return err
+	}
+
+	// Load the user and project configuration files. Their settings apply when the flags are not given.
+	currentDir, err := os.Getwd()
+	if err == nil {
+		err = config.LoadConfigFiles(currentDir)
+	}
+	if err != nil {
+		terminal.ErrorMessage("Could not load the configuration", err)
+		return err
+	}
+	appConfig = config.GetAppConfig()
+
+	return nil
+}
+
+// configurationHelp describes the configuration files. It is appended to the long description of the commands
+// that use their settings.
+var configurationHelp = fmt.Sprintf(`Defaults can be set in the user configuration file, '~/.config/cloney/config.yaml' (or the path in 'CLONEY_CONFIG'),
+and in a '%s' file in the current directory, which takes precedence. Flags and environment variables take precedence over both.
+'token_env' and 'cache_dir' can only be set in the user configuration file.
+  default_branch: develop             # Branch used when neither '--branch' nor '--tag' is given.
+  token_env: [GITLAB_TOKEN]           # Environment variables holding the git token, after 'CLONEY_GIT_TOKEN'. User file only.
+  cache_dir: ~/.cache/cloney          # Directory for the repositories fetched while the command runs. User file only.
+  variables: { author: Jane Doe }     # Variables used when no other source defines them.
+  aliases:                            # Short names for template repositories.
+    svc: https://github.com/username/service.git`, appConfig.ProjectConfigFileName)
+
+// getBranchAndTag returns the git branch and tag given with the '--branch' and '--tag' flags.
+// If neither flag is given, the default branch of the configuration is used.
+func getBranchAndTag(cmd *cobra.Command) (string, string) {
+	branch, _ := cmd.Flags().GetString("branch")
+	tag, _ := cmd.Flags().GetString("tag")
+	if !cmd.Flags().Changed("branch") {
+		branch = ""
+		if tag == "" {
+			branch = appConfig.DefaultBranch
+		}
+	}
+	return branch, tag
+}
+
+// AddGlobalFlags defines the flags shared by all commands, which control the messages printed to the terminal.
+func AddGlobalFlags(rootCmd *cobra.Command) {
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print details about each step, such as the repositories fetched")
+	rootCmd.PersistentFlags().Bool("debug", false, "Print details about each step and each file: ignored, copied, rendered or removed")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Only print errors and the result of the command")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colors, also disabled by the 'NO_COLOR' environment variable, and for the output or the error output that is not a terminal")
+	rootCmd.PersistentFlags().String("output-format", terminal.TEXT_OUTPUT_FORMAT, fmt.Sprintf("Format of the result of the 'info', 'validate', 'lint', 'clone' and 'dry-run' commands (%s), other messages are printed to the standard error with 'json' and 'yaml'", strings.Join(terminal.SupportedOutputFormats, ", ")))
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "debug", "quiet")
+}
+
+// addTimeoutFlag defines the flag used to limit the duration of a command.
+func addTimeoutFlag(cmd *cobra.Command) {
+	cmd.Flags().Duration("timeout", 0, "Maximum duration of the command, such as '30s' or '5m' (no limit by default)")
+}
+
+// commandContext returns the context of a command, which is cancelled when the command is interrupted,
+// or once the duration of the '--timeout' flag elapsed, if the command has it.
+// The returned function releases the resources of the context and must be called once the command returns.
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// variablesPrecedenceHelp describes the order in which the variables sources are merged.
+// It is appended to the long description of the commands that accept variables.
+var variablesPrecedenceHelp = fmt.Sprintf(`Variables are merged from the following sources, each one taking precedence over the previous ones:
+  1. Default values defined in the template repository metadata file.
+  2. Default variables of the configuration files.
+  3. Variables files or inline YAML given with '--variables', in order. Defaults to '%s'.
+  4. Environment variables named 'CLONEY_VAR_<name>'.
+  5. Values given with '--set', '--set-string' and then '--set-file', in order.`, appConfig.DefaultUserVariablesFileName)
+
+// addUserVariablesFlags defines the flags used to provide template variables.
+func addUserVariablesFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("variables", "v", []string{}, fmt.Sprintf("Path to a template variables file (YAML, JSON, TOML or .env) or raw YAML, can be repeated (default \"%s\")", appConfig.DefaultUserVariablesFileName))
+	cmd.Flags().StringArray("set", []string{}, "Set a variable, such as 'db.port=5432' or 'services[0].name=api', can be repeated")
+	cmd.Flags().StringArray("set-string", []string{}, "Set a variable as a string, such as 'version=1.10', can be repeated")
+	cmd.Flags().StringArray("set-file", []string{}, "Set a variable to the content of a file, such as 'token=./token.txt', can be repeated")
+}
+
+// getUserVariablesSources reads the flags used to provide template variables.
+func getUserVariablesSources(cmd *cobra.Command) steps.UserVariablesSources {
+	var sources steps.UserVariablesSources
+	sources.Files, _ = cmd.Flags().GetStringArray("variables")
+	sources.SetValues, _ = cmd.Flags().GetStringArray("set")
+	sources.SetStringValues, _ = cmd.Flags().GetStringArray("set-string")
+	sources.SetFileValues, _ = cmd.Flags().GetStringArray("set-file")
+	return sources
+}
+
+// resetUserVariablesFlags resets the flags used to provide template variables.
+// Array flags append values on every 'Set' call, so they are replaced instead.
+func resetUserVariablesFlags(cmd *cobra.Command) {
+	for _, name := range []string{"variables", "set", "set-string", "set-file"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil {
+			flag.Value.(pflag.SliceValue).Replace([]string{})
+		}
+	}
 }
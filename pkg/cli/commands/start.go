@@ -106,8 +106,9 @@ func startCmdRun(cmd *cobra.Command, args []string) error {
 	rawMetadata += "    description: Whether to enable dark mode or not.\n"
 	rawMetadata += "    example: true\n"
 
-	// Suppress prints for common-steps functions.
-	steps.SetSuppressPrints(true)
+	// The messages of the steps are only printed in the verbose and debug log levels.
+	restoreLogLevel := terminal.LowerLogLevel()
+	defer restoreLogLevel()
 
 	// Create and validate a reference to the Cloney example repository.
 	// Reference the 'basic' branch, which contains a basic template repository.
@@ -126,12 +127,21 @@ func startCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	clonePath, _ := steps.CalculatePath(output, repository.GetName())
 
-	// Clone the repository.
-	err = steps.CloneRepository(repository, clonePath)
+	// Clone the repository, stopping when the command is interrupted.
+	// A directory created by an interrupted or failed clone is deleted, so nothing is left half-written.
+	ctx, cancel := commandContext(cmd)
+	defer cancel()
+	_, statErr := os.Stat(clonePath)
+	err = steps.CloneRepository(ctx, repository, clonePath)
 	if err != nil {
+		if os.IsNotExist(statErr) {
+			os.RemoveAll(clonePath)
+		}
 		return err
 	}
 
+	restoreLogLevel()
+
 	// Delete the .git directory.
 	gitDirPath := filepath.Join(clonePath, ".git")
 	os.RemoveAll(gitDirPath)
@@ -173,8 +183,8 @@ The 'cloney start' command will create a directory with the necessary files to s
 			"  cloney start",
 			"  cloney start -y",
 		}, "\n"),
-		PersistentPreRun: persistentPreRun,
-		RunE:             startCmdRun,
+		PersistentPreRunE: persistentPreRun,
+		RunE:              startCmdRun,
 	}
 
 	// Define command-line flags for the 'start' command.
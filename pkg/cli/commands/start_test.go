@@ -10,7 +10,7 @@ import (
 )
 
 // testStartCmd represents a command instance used for testing.
-var testStartCmd = CreateStartCommand()
+var testStartCmd = newTestCommand(CreateStartCommand())
 
 // TestMetadataProperties is a struct to store metadata properties for testing.
 type TestMetadataProperties struct {
@@ -1,28 +1,26 @@
 package steps
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/cloney"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/config"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/git"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/metadata"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"gopkg.in/yaml.v3"
 )
 
 // This file defines common steps used by multiple commands.
 
-// Global variables:
-// suppressPrints is a flag to determine if the functions in this package should print to the messages.
-var suppressPrints bool
-
-// SetSuppressPrints sets the suppressPrints flag.
-func SetSuppressPrints(value bool) {
-	suppressPrints = value
-}
-
 // GetCurrentWorkingDirectory returns the current working directory.
 func GetCurrentWorkingDirectory() (string, error) {
 	currentDir, err := os.Getwd()
@@ -34,25 +32,107 @@ func GetCurrentWorkingDirectory() (string, error) {
 	return currentDir, nil
 }
 
+// UserVariablesSources holds the sources of the template variables provided by the user.
+type UserVariablesSources struct {
+	// Files is the list of variables files or inline YAML strings.
+	Files []string
+
+	// SetValues is the list of '--set' expressions, whose values are parsed as YAML scalars.
+	SetValues []string
+
+	// SetStringValues is the list of '--set-string' expressions, whose values are kept as strings.
+	SetStringValues []string
+
+	// SetFileValues is the list of '--set-file' expressions, whose values are read from files.
+	SetFileValues []string
+}
+
 // GetUserVariablesMap returns the template variables provided by the user.
-func GetUserVariablesMap(currentDir, variables string) (map[string]interface{}, error) {
-	var err error
-	var variablesMap map[string]interface{}
+// The sources are deep merged in the following order, each one taking precedence over the previous ones:
+//  1. Variables files or inline YAML, in the order they were given.
+//     If none is given, the default variables file is used if it exists, and a warning is printed otherwise.
+//  2. Environment variables named 'CLONEY_VAR_<name>'.
+//  3. '--set', '--set-string' and then '--set-file' values.
+//
+// Default values from the template repository metadata file are applied later, for variables that are still not defined.
+func GetUserVariablesMap(currentDir string, sources UserVariablesSources) (map[string]interface{}, error) {
+	variables := sources.Files
 
-	// First, assume 'variables' is a raw YAML string.
-	variablesMap, err = metadata.NewCloneyUserVariablesFromRawYAML(variables)
-	if err != nil {
-		// In case of error, assume 'variables' is a file path.
-		variablesMap, err = metadata.NewCloneyUserVariablesFromFile(variables)
+	// The default variables of the configuration files have the lowest precedence of the user variables.
+	variablesMap := metadata.MergeUserVariables(make(map[string]interface{}), config.GetAppConfig().DefaultVariables)
+
+	// If no variables source was given, use the default variables file, if it exists.
+	if len(variables) == 0 {
+		defaultFileName := config.GetAppConfig().DefaultUserVariablesFileName
+		if _, err := os.Stat(defaultFileName); err == nil {
+			variables = []string{defaultFileName}
+		} else {
+			terminal.WarningMessage(
+				fmt.Sprintf("No '%s' file found in the current directory, only default values and other sources will be used", defaultFileName),
+			)
+		}
+	}
+
+	for _, variablesSource := range variables {
+		// Each source is either a file path or inline YAML.
+		// Errors are returned instead of ignored, so typos do not show up later as missing variables.
+		sourceMap, err := metadata.NewCloneyUserVariablesFromSource(variablesSource)
 		if err != nil {
-			// If it is not a file path, return an empty map.
-			return map[string]interface{}{}, nil
+			terminal.ErrorMessage("Could not read your template variables", err)
+			return nil, err
+		}
+		variablesMap = metadata.MergeUserVariables(variablesMap, sourceMap)
+	}
+
+	// Variables defined in 'CLONEY_VAR_<name>' environment variables take precedence over the files.
+	variablesMap = metadata.MergeUserVariables(variablesMap, metadata.NewCloneyUserVariablesFromEnv(os.Environ()))
+
+	// Finally, apply the '--set', '--set-string' and '--set-file' values.
+	for _, setValue := range sources.SetValues {
+		if err := metadata.ApplySetExpression(variablesMap, setValue, false); err != nil {
+			terminal.ErrorMessage("Invalid '--set' value", err)
+			return nil, err
+		}
+	}
+	for _, setStringValue := range sources.SetStringValues {
+		if err := metadata.ApplySetExpression(variablesMap, setStringValue, true); err != nil {
+			terminal.ErrorMessage("Invalid '--set-string' value", err)
+			return nil, err
+		}
+	}
+	for _, setFileValue := range sources.SetFileValues {
+		if err := metadata.ApplySetFileExpression(variablesMap, setFileValue); err != nil {
+			terminal.ErrorMessage("Invalid '--set-file' value", err)
+			return nil, err
 		}
 	}
 
 	return variablesMap, nil
 }
 
+// PrintUserVariables prints the template variables as YAML. The values of secret variables are printed as "********".
+func PrintUserVariables(cloneyMetadata *metadata.CloneyMetadata, variablesMap map[string]interface{}) error {
+	variablesMap = cloneyMetadata.RedactSecrets(variablesMap)
+
+	// In the 'json' and 'yaml' output formats, the variables are the result of the command.
+	if terminal.IsStructuredOutput() {
+		err := terminal.Output(variablesMap)
+		if err != nil {
+			terminal.ErrorMessage("Could not print the template variables", err)
+		}
+		return err
+	}
+
+	variablesYAML, err := yaml.Marshal(variablesMap)
+	if err != nil {
+		terminal.ErrorMessage("Could not print the template variables", err)
+		return err
+	}
+	terminal.Messagef("%s", variablesYAML)
+
+	return nil
+}
+
 // CreateAndValidateRepository creates the Git repository instance and validates it.
 func CreateAndValidateRepository(repositoryURL, branch, tag string) (*git.GitRepository, error) {
 	// Create the Git repository instance.
@@ -68,9 +148,7 @@ func CreateAndValidateRepository(repositoryURL, branch, tag string) (*git.GitRep
 		terminal.ErrorMessage("Error validating repository", err)
 		return nil, err
 	}
-	if !suppressPrints {
-		terminal.OKMessage("The template repository reference is valid")
-	}
+	terminal.OKMessage("The template repository reference is valid")
 
 	return repository, nil
 }
@@ -78,15 +156,48 @@ func CreateAndValidateRepository(repositoryURL, branch, tag string) (*git.GitRep
 // AuthenticateToRepository authenticates to the repository if a token is provided.
 func AuthenticateToRepository(repository *git.GitRepository, gitToken string) {
 	// If the token is empty, try to get it from the environment variable.
-	if gitToken == "" {
-		gitToken = os.Getenv("CLONEY_GIT_TOKEN")
-	}
+	gitToken = tokenOrEnvironment(gitToken)
 	// Only if the token is not empty, authenticate to the repository.
 	if gitToken != "" {
 		repository.AuthenticateWithToken(gitToken)
 	}
 }
 
+// tokenOrEnvironment returns the git token, or, if it is empty, the value of the 'CLONEY_GIT_TOKEN' environment variable,
+// or of the first environment variable of the configuration files that is set.
+func tokenOrEnvironment(gitToken string) string {
+	if gitToken != "" {
+		return gitToken
+	}
+	for _, name := range append([]string{"CLONEY_GIT_TOKEN"}, config.GetAppConfig().TokenEnvironmentVariables...) {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// fetchOptions returns the options used to fetch remote templates and partial libraries, with the default branch and
+// the cache directory of the configuration files. If the token is empty, it is read from the environment, see 'tokenOrEnvironment'.
+func fetchOptions(gitToken string) cloney.FetchOptions {
+	appConfig := config.GetAppConfig()
+	return cloney.FetchOptions{
+		Token:         tokenOrEnvironment(gitToken),
+		DefaultBranch: appConfig.DefaultBranch,
+		CacheDir:      appConfig.CacheDirectory,
+	}
+}
+
+// ResolveAlias returns the source of a template repository alias defined in the configuration files,
+// or the given source if it is not an alias. Aliases take precedence over local directories with the same name.
+func ResolveAlias(source string) string {
+	resolved := config.GetAppConfig().ResolveAlias(source)
+	if resolved != source {
+		terminal.VerboseMessage(fmt.Sprintf("The alias '%s' refers to '%s'", source, resolved))
+	}
+	return resolved
+}
+
 // CalculatePath calculates the absolute path for a given relative or absolute path string.
 // If the path is already absolute, it is returned as-is.
 // If the path is empty, the defaultName is appended to the current working directory.
@@ -110,16 +221,128 @@ func CalculatePath(path string, defaultName string) (string, error) {
 	return newPath, nil
 }
 
+// CheckTargetDirectory checks that a directory can receive generated files.
+// It returns an error if the directory already exists and is not empty, unless 'force' is true.
+func CheckTargetDirectory(targetPath string, force bool) error {
+	empty, err := templates.IsDirectoryEmpty(targetPath)
+	if err != nil {
+		terminal.ErrorMessage(fmt.Sprintf("Could not read the directory '%s'", targetPath), err)
+		return err
+	}
+	if !empty && !force {
+		err = fmt.Errorf("directory '%s' already exists and is not empty, use the '--force' flag to replace it", targetPath)
+		terminal.ErrorMessage("Could not clone the repository", err)
+		return err
+	}
+	return nil
+}
+
+// CreateStagingDirectory creates a staging directory next to the target directory, where files are generated
+// before being moved into place with 'CommitStagingDirectory'. If 'merge' is true, the staging directory is created
+// in a temporary directory instead, and its files are merged into the target directory with 'MergeIntoDirectory'.
+func CreateStagingDirectory(targetPath string, merge bool) (*templates.StagingDirectory, error) {
+	var staging *templates.StagingDirectory
+	var err error
+	if merge {
+		staging, err = templates.NewMergeStagingDirectory(targetPath)
+	} else {
+		staging, err = templates.NewStagingDirectory(targetPath)
+	}
+	if err != nil {
+		terminal.ErrorMessage("Could not create the staging directory", err)
+		return nil, err
+	}
+	return staging, nil
+}
+
+// CommitStagingDirectory moves the staging directory into place, replacing the target directory if it exists.
+func CommitStagingDirectory(staging *templates.StagingDirectory) error {
+	err := staging.Commit()
+	if err != nil {
+		terminal.ErrorMessage("Could not move the generated files into place", err)
+		return err
+	}
+	return nil
+}
+
+// ValidateConflictStrategy checks that a conflict strategy is supported and can be used.
+// The 'prompt' strategy requires the standard input to be a terminal.
+func ValidateConflictStrategy(strategy string) error {
+	var err error
+	if !slices.Contains(templates.SupportedConflictStrategies, strategy) {
+		err = fmt.Errorf("unsupported conflict strategy '%s', expected one of: %s", strategy, strings.Join(templates.SupportedConflictStrategies, ", "))
+	} else if strategy == templates.PROMPT_CONFLICT_STRATEGY && !terminal.IsInputTerminal() {
+		err = fmt.Errorf("the '%s' conflict strategy requires an interactive terminal", strategy)
+	}
+	if err != nil {
+		terminal.ErrorMessage("Invalid conflict strategy", err)
+		return err
+	}
+	return nil
+}
+
+// MergeIntoDirectory merges the generated files of the staging directory into an existing directory,
+// resolving conflicts with the given strategy, and prints a report of what happened to each file.
+func MergeIntoDirectory(staging *templates.StagingDirectory, strategy string) (*templates.MergeReport, error) {
+	scanner := bufio.NewScanner(os.Stdin)
+	confirmOverwrite := func(relativePath string) (bool, error) {
+		answer := terminal.InputWithDefaultValue(scanner, fmt.Sprintf("File '%s' already exists. Overwrite it? (y/n)", relativePath), "n")
+		return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes"), nil
+	}
+
+	report, err := templates.MergeDirectory(staging.Path, staging.TargetPath, strategy, confirmOverwrite)
+	if err != nil {
+		terminal.ErrorMessage("Could not merge the generated files into the directory", err)
+		return nil, err
+	}
+
+	// In the 'json' and 'yaml' output formats, the report is printed by the command as its result.
+	if !terminal.IsStructuredOutput() {
+		terminal.Message(fmt.Sprintf("\n%s", terminal.WhiteBoldUnderline("Report")))
+		for _, section := range []struct {
+			title string
+			paths []string
+		}{
+			{"Created", report.Created},
+			{"Overwritten", report.Overwritten},
+			{"Backed up and overwritten", report.BackedUp},
+			{"Merged with conflict markers", report.Merged},
+			{"Skipped", report.Skipped},
+			{"Unchanged", report.Unchanged},
+		} {
+			if len(section.paths) == 0 {
+				continue
+			}
+			terminal.Message(fmt.Sprintf("\n%s (%d):", section.title, len(section.paths)))
+			for _, path := range section.paths {
+				terminal.Message(fmt.Sprintf("  - %s", path))
+			}
+		}
+	}
+
+	return report, nil
+}
+
 // CloneRepository clones the repository.
-func CloneRepository(repository *git.GitRepository, clonePath string) error {
-	err := repository.Clone(clonePath)
+func CloneRepository(ctx context.Context, repository *git.GitRepository, clonePath string) error {
+	reference := repository.Branch
+	if repository.Tag != "" {
+		reference = repository.Tag
+	}
+	terminal.VerboseMessage(fmt.Sprintf("Cloning '%s' at '%s' into '%s'", repository.URL, reference, clonePath))
+
+	// Display the progress messages of the git server while cloning.
+	progress := terminal.NewProgress("Cloning the template repository", 0)
+	if progress.Enabled() {
+		repository.Progress = progress
+	}
+	err := repository.Clone(ctx, clonePath)
+	progress.Done()
 	if err != nil {
 		terminal.ErrorMessage("Could not clone repository", err)
 		return err
 	}
-	if !suppressPrints {
-		terminal.OKMessage("The template repository was cloned")
-	}
+	terminal.OKMessage("The template repository was cloned")
 
 	return nil
 }
@@ -136,13 +359,68 @@ func ReadRepositoryMetadata(metadataFilePath string) (string, error) {
 		terminal.ErrorMessage(fmt.Sprintf("Could not read the \"%s\" template repository metadata file", config.GetAppConfig().MetadataFileName), err)
 		return "", err
 	}
-	if !suppressPrints {
-		terminal.OKMessage("The template repository metadata file was found")
-	}
+	terminal.OKMessage("The template repository metadata file was found")
 
 	return string(metadataBytes), nil
 }
 
+// GetLocalRepositoryPath returns the absolute path of a local template repository,
+// or an empty string if 'repositorySource' is a git repository URL.
+func GetLocalRepositoryPath(repositorySource string) string {
+	if git.MatchesGitRepositoryURL(repositorySource) {
+		return ""
+	}
+	sourcePath, _ := CalculatePath(repositorySource, "")
+	return sourcePath
+}
+
+// GetTemplateName returns the name of a template from its source: the repository name of a git repository URL,
+// or the base name of a local path.
+func GetTemplateName(source string) string {
+	if git.MatchesGitRepositoryURL(source) {
+		return (&git.GitRepository{URL: source}).GetName()
+	}
+	return filepath.Base(filepath.Clean(source))
+}
+
+// GetRepositoryMetadataContent returns the content of the metadata file of a template repository.
+// If 'repositorySource' is a git repository URL, the metadata file is read from the remote repository.
+// Otherwise, 'repositorySource' is assumed to be a local path.
+func GetRepositoryMetadataContent(ctx context.Context, repositorySource, branch, tag, token string) (string, error) {
+	// If the argument is not a git repository URL, assume it is a local path.
+	if !git.MatchesGitRepositoryURL(repositorySource) {
+		// Calculate the directory path.
+		sourcePath, err := CalculatePath(repositorySource, "")
+		if err != nil {
+			return "", err
+		}
+
+		// Get the metadata file content.
+		metadataFilePath := filepath.Join(sourcePath, config.GetAppConfig().MetadataFileName)
+		return ReadRepositoryMetadata(metadataFilePath)
+	}
+
+	// Create and validate the git repository.
+	repository, err := CreateAndValidateRepository(repositorySource, branch, tag)
+	if err != nil {
+		return "", err
+	}
+
+	// If a token is provided, authenticate with it.
+	AuthenticateToRepository(repository, token)
+
+	// Get the metadata file content.
+	metadataContent, err := repository.GetFileContent(ctx, config.GetAppConfig().MetadataFileName)
+	if err != nil {
+		terminal.ErrorMessage(
+			fmt.Sprintf("Error reading the repository '%s' metadata file:", config.GetAppConfig().MetadataFileName), err,
+		)
+		return "", err
+	}
+
+	return metadataContent, nil
+}
+
 // ParseRepositoryMetadata parses the repository metadata.
 func ParseRepositoryMetadata(metadataContent string, supportedManifestVersions []string) (*metadata.CloneyMetadata, error) {
 	// Create the metadata struct from raw YAML data.
@@ -151,13 +429,77 @@ func ParseRepositoryMetadata(metadataContent string, supportedManifestVersions [
 		terminal.ErrorMessage("Could not parse the template repository template metadata", err)
 		return nil, err
 	}
-	if !suppressPrints {
-		terminal.OKMessage("The template repository metadata file is valid")
-	}
+	terminal.OKMessage("The template repository metadata file is valid")
 
 	return cloneyMetadata, nil
 }
 
+// GetIgnorePaths returns the ignore patterns of a template repository: the paths known to be irrelevant to templates,
+// the 'ignore_paths' of the metadata file and the patterns of the '.cloneyignore' file, if it exists, in this order.
+// Since the last matching pattern wins, the '.cloneyignore' file can re-include paths with '!' patterns.
+func GetIgnorePaths(src string, cloneyMetadata *metadata.CloneyMetadata) ([]string, error) {
+	ignorePaths, err := cloney.IgnorePaths(src, cloneyMetadata)
+	if err != nil {
+		terminal.ErrorMessage("Could not read the ignore patterns", err)
+		return nil, err
+	}
+	terminal.VerboseMessage(fmt.Sprintf("Ignore patterns: %s", strings.Join(ignorePaths, ", ")))
+	return ignorePaths, nil
+}
+
+// ResolveExtends finds or fetches the base templates extended by a template repository, recursively, and returns them
+// from the closest to the deepest, along with the metadata of the template repository merged with theirs.
+// See 'cloney.ResolveExtends'. If the token is empty, the 'CLONEY_GIT_TOKEN' environment variable is used.
+// The returned function deletes the temporary directories and must be called once the base templates are no longer needed.
+func ResolveExtends(ctx context.Context, src string, cloneyMetadata *metadata.CloneyMetadata, token string) (*metadata.CloneyMetadata, []cloney.Base, func(), error) {
+	merged, bases, cleanup, err := cloney.ResolveExtends(ctx, src, cloneyMetadata, fetchOptions(token))
+	if err != nil {
+		terminal.ErrorMessage("Could not resolve the base templates", err)
+		return nil, nil, nil, err
+	}
+	for _, base := range bases {
+		terminal.VerboseMessage(fmt.Sprintf("The base template '%s' was found at '%s'", base.Metadata.Name, base.Path))
+	}
+	if len(bases) > 0 {
+		terminal.OKMessage("The base templates were resolved")
+	}
+
+	return merged, bases, cleanup, nil
+}
+
+// ComposeTemplates finds or fetches several templates, with their base templates, to generate them into the same directory.
+// See 'cloney.ComposeTemplates'. If the token is empty, the 'CLONEY_GIT_TOKEN' environment variable is used.
+// The returned function deletes the temporary directories and must be called once the templates are no longer needed.
+func ComposeTemplates(ctx context.Context, compositionTemplates []metadata.CompositionTemplate, collisionStrategy, relativeTo, token string) (*metadata.CloneyMetadata, []cloney.Base, func(), error) {
+	composed, layers, cleanup, err := cloney.ComposeTemplates(ctx, compositionTemplates, collisionStrategy, relativeTo, fetchOptions(token))
+	if err != nil {
+		terminal.ErrorMessage("Could not compose the templates", err)
+		return nil, nil, nil, err
+	}
+	terminal.OKMessage(fmt.Sprintf("%d templates were composed", len(compositionTemplates)))
+
+	return composed, layers, cleanup, nil
+}
+
+// GetPartials returns the partial libraries of a template repository, see 'cloney.FetchPartials'.
+// If the token is empty, the 'CLONEY_GIT_TOKEN' environment variable is used.
+// The returned function deletes the temporary directories and must be called once the partials are no longer needed.
+func GetPartials(ctx context.Context, src string, cloneyMetadata *metadata.CloneyMetadata, bases []cloney.Base, token string) ([]templates.PartialLibrary, func(), error) {
+	partials, cleanup, err := cloney.FetchPartials(ctx, src, cloneyMetadata, bases, fetchOptions(token))
+	if err != nil {
+		terminal.ErrorMessage("Could not get the partial libraries", err)
+		return nil, nil, err
+	}
+	for _, partial := range partials {
+		terminal.VerboseMessage(fmt.Sprintf("The partials of '%s' are available", partial.Name))
+	}
+	if len(cloneyMetadata.Configuration.Libraries) > 0 {
+		terminal.OKMessage("The partial libraries were fetched")
+	}
+
+	return partials, cleanup, nil
+}
+
 // DeleteIgnoredPaths removes files and directories from the specified 'directory' if their
 // paths match any of the patterns listed in 'cloneyMetadata.Configuration.IgnorePaths'.
 // It iterates through the ignore paths and deletes them recursively.
@@ -172,6 +514,32 @@ func DeleteIgnoredPaths(directory string, ignorePaths []string) {
 	}
 }
 
+// PromptSecretVariables prompts the user for the secret variables that are required but not defined,
+// without echoing the typed characters. It does nothing if the standard input is not a terminal.
+func PromptSecretVariables(cloneyMetadata *metadata.CloneyMetadata, variablesMap map[string]interface{}) error {
+	if !terminal.IsInputTerminal() {
+		return nil
+	}
+	for _, variable := range cloneyMetadata.Variables {
+		if _, contains := variablesMap[variable.Name]; contains || !variable.Secret || variable.Default != nil {
+			continue
+		}
+		value, err := terminal.InputSecret(fmt.Sprintf("Enter the value of the secret variable '%s'", variable.Name))
+		if err != nil {
+			terminal.ErrorMessage("Error reading user input", err)
+			return err
+		}
+		// Keep the value as a string if the variable is a string, otherwise parse it as YAML.
+		if metadata.VariableType(variable.Example) == metadata.STRING_VARIABLE_TYPE {
+			variablesMap[variable.Name] = value
+		} else {
+			variablesMap[variable.Name] = metadata.ParseYAMLScalar(value)
+		}
+	}
+
+	return nil
+}
+
 // MatchUserVariables matches the user variables with the template variables.
 func MatchUserVariables(cloneyMetadata *metadata.CloneyMetadata, variablesMap map[string]interface{}) error {
 	// Validate if the user variables match the template variables.
@@ -182,24 +550,32 @@ func MatchUserVariables(cloneyMetadata *metadata.CloneyMetadata, variablesMap ma
 		terminal.ErrorMessage("Error validating your template variables", err)
 		return err
 	}
-	if !suppressPrints {
-		terminal.OKMessage("Your variables are valid and match the template repository variables")
-	}
+
+	// From now on, secret values are redacted in the error messages printed to the terminal.
+	terminal.AddSecrets(cloneyMetadata.SecretValues(variablesMap)...)
+	terminal.OKMessage("Your variables are valid and match the template repository variables")
 
 	return nil
 }
 
 // FillDirectory fills template variables in files within the source directory.
 func FillDirectory(
+	ctx context.Context,
 	src string,
 	ignorePaths []string,
 	outputInTerminal bool,
-	variablesMap map[string]interface{}) error {
+	variablesMap map[string]interface{},
+	configuration metadata.CloneyMetadataConfiguration,
+	partials []templates.PartialLibrary,
+	bases []cloney.Base) error {
 	// Create a new template filler with the provided variables.
 	filler := templates.NewTemplateFiller(variablesMap)
+	filler.RenderOptions = cloney.RenderOptions(configuration, partials, bases)
+	// The file modes were already validated when parsing the metadata file.
+	filler.FileModes, _ = configuration.FileModes()
 
 	// Fill the template variables in the source directory.
-	err := filler.FillDirectory(src, ignorePaths, outputInTerminal)
+	err := filler.FillDirectory(ctx, src, ignorePaths, outputInTerminal)
 	if err != nil {
 		if outputInTerminal {
 			terminal.ErrorMessage("Failed to print results to the terminal", err)
@@ -209,9 +585,81 @@ func FillDirectory(
 		return err
 	}
 
-	if !suppressPrints && !outputInTerminal {
+	if !outputInTerminal {
 		terminal.OKMessage("Template variables successfully filled")
 	}
 
 	return nil
 }
+
+// RenderDirectory renders the template files of the source directory into the destination directory.
+// The destination directory is only replaced once all files were rendered successfully.
+func RenderDirectory(
+	ctx context.Context,
+	src string,
+	dest string,
+	ignorePaths []string,
+	variablesMap map[string]interface{},
+	configuration metadata.CloneyMetadataConfiguration,
+	partials []templates.PartialLibrary,
+	bases []cloney.Base) error {
+	// Create a new template filler with the provided variables.
+	filler := templates.NewTemplateFiller(variablesMap)
+	filler.RenderOptions = cloney.RenderOptions(configuration, partials, bases)
+	// The file modes were already validated when parsing the metadata file.
+	filler.FileModes, _ = configuration.FileModes()
+
+	// Render the template files into the destination directory.
+	err := filler.RenderDirectory(ctx, src, dest, ignorePaths)
+	if err != nil {
+		terminal.ErrorMessage("Failed to fill the template variables", err)
+		return err
+	}
+
+	terminal.OKMessage("Template variables successfully filled")
+
+	return nil
+}
+
+// RenderFiles renders the template files of the source directory in memory, without writing them.
+func RenderFiles(
+	ctx context.Context,
+	src string,
+	ignorePaths []string,
+	variablesMap map[string]interface{},
+	configuration metadata.CloneyMetadataConfiguration,
+	partials []templates.PartialLibrary,
+	bases []cloney.Base) (*templates.MemoryFS, error) {
+	// Create a new template filler with the provided variables.
+	filler := templates.NewTemplateFiller(variablesMap)
+	filler.RenderOptions = cloney.RenderOptions(configuration, partials, bases)
+	// The file modes were already validated when parsing the metadata file.
+	filler.FileModes, _ = configuration.FileModes()
+
+	// Render the template files in memory.
+	rendered := templates.NewMemoryFS()
+	err := filler.Render(ctx, templates.NewDiskFS(src), ignorePaths, rendered)
+	if err != nil {
+		terminal.ErrorMessage("Failed to fill the template variables", err)
+		return nil, err
+	}
+
+	return rendered, nil
+}
+
+// LintDirectory lints the template files within the source directory.
+func LintDirectory(src string, ignorePaths []string, cloneyMetadata *metadata.CloneyMetadata, partials []templates.PartialLibrary, bases []cloney.Base) ([]templates.LintIssue, error) {
+	// Collect the names of the variables declared in the metadata file.
+	var declaredVariables []string
+	for _, variable := range cloneyMetadata.Variables {
+		declaredVariables = append(declaredVariables, variable.Name)
+	}
+
+	issues, err := templates.LintDirectory(src, ignorePaths, declaredVariables, cloney.RenderOptions(cloneyMetadata.Configuration, partials, bases))
+	if err != nil {
+		terminal.ErrorMessage("Failed to lint the template files", err)
+		return nil, err
+	}
+
+	return issues, nil
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/cli/commands/steps"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
 
 	"github.com/spf13/cobra"
@@ -31,21 +32,45 @@ func validateCmdRun(cmd *cobra.Command, args []string) error {
 	metadataFilePath := filepath.Join(sourcePath, appConfig.MetadataFileName)
 	metadataContent, err := steps.ReadRepositoryMetadata(metadataFilePath)
 	if err != nil {
-		return err
+		return printValidationError(err)
 	}
 
 	// Parse the metadata file.
 	_, err = steps.ParseRepositoryMetadata(metadataContent, appConfig.SupportedManifestVersions)
 	if err != nil {
-		return err
+		return printValidationError(err)
 	}
 
 	// If the metadata file was parsed successfully, then the template is valid.
 	terminal.Message("\nYour Cloney template is valid!")
 
+	// In the 'json' and 'yaml' output formats, print that no problem was found.
+	if terminal.IsStructuredOutput() {
+		return printResult(validationResult{Valid: true, Diagnostics: []diagnostic{}})
+	}
+
 	return nil
 }
 
+// printValidationError prints the error found in the metadata file as a diagnostic, in the 'json' and 'yaml'
+// output formats, and returns it.
+func printValidationError(err error) error {
+	if terminal.IsStructuredOutput() {
+		metadataDiagnostic := diagnostic{
+			Severity: templates.LINT_ERROR_SEVERITY,
+			File:     appConfig.MetadataFileName,
+			Message:  err.Error(),
+		}
+		printResult(validationResult{Valid: false, Diagnostics: []diagnostic{metadataDiagnostic}})
+	}
+	return err
+}
+
+// ResetValidateCommandFlags resets the flags of the 'validate' command.
+func ResetValidateCommandFlags(cmd *cobra.Command) {
+	resetOutputFormatFlag(cmd)
+}
+
 // CreateValidateCommand creates the 'validate' command.
 func CreateValidateCommand() *cobra.Command {
 	validateCmd := &cobra.Command{
@@ -60,9 +85,12 @@ It checks if the repository has a metadata file, and if it has the required fiel
 			"  validate",
 			"  validate ./path/to/my/template",
 		}, "\n"),
-		PersistentPreRun: persistentPreRun,
-		RunE:             validateCmdRun,
+		PersistentPreRunE: persistentPreRun,
+		RunE:              validateCmdRun,
 	}
 
+	// Define command-line flags for the 'validate' command.
+	setHasResult(validateCmd)
+
 	return validateCmd
 }
@@ -1,3 +1,60 @@
 package commands
 
-// TODO: Add 'validate' command tests.
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testValidateCommand represents a command instance used for testing.
+var testValidateCommand = newTestCommand(CreateValidateCommand())
+
+// TestValidateCommandWithJSONOutputFormat tests the "validate" command with the 'json' output format.
+// It should print whether the template is valid, with a diagnostic for the metadata file if it is not.
+func TestValidateCommandWithJSONOutputFormat(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-validate-project")
+
+	// Redirect stdout to a buffer.
+	var buffer bytes.Buffer
+	terminal.SetTestMode(&buffer)
+	defer terminal.SetTestMode(nil)
+
+	// Execute the "validate" command on the valid template.
+	testValidateCommand.SetArgs([]string{"./test-validate-project", "--output-format", "json"})
+	err := testValidateCommand.Execute()
+
+	// Assert that the "validate" command did not return an error and printed that the template is valid.
+	assert.Nil(err)
+	var result validationResult
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &result))
+	assert.True(result.Valid)
+	assert.Empty(result.Diagnostics)
+
+	// Make the metadata file invalid, and execute the "validate" command again.
+	err = os.WriteFile(filepath.Join("test-validate-project", appConfig.MetadataFileName), []byte("name: Invalid"), os.ModePerm)
+	assert.NoError(err)
+	buffer.Reset()
+	err = testValidateCommand.Execute()
+	ResetValidateCommandFlags(testValidateCommand)
+
+	// Assert that the "validate" command returned an error and printed a diagnostic for the metadata file.
+	assert.NotNil(err)
+	result = validationResult{}
+	assert.Nil(json.Unmarshal(buffer.Bytes(), &result))
+	assert.False(result.Valid)
+	assert.Len(result.Diagnostics, 1)
+	assert.Equal(appConfig.MetadataFileName, result.Diagnostics[0].File)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-validate-project")
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/cli/commands/steps"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/metadata"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+// varsCmdRun is the function that runs when the 'vars' command is called.
+func varsCmdRun(cmd *cobra.Command, args []string) error {
+	// Get command-line arguments.
+	var repositorySource string
+	if len(args) >= 1 {
+		repositorySource = steps.ResolveAlias(args[0])
+	}
+	branch, tag := getBranchAndTag(cmd)
+	token, _ := cmd.Flags().GetString("token")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	outputInTerminal, _ := cmd.Flags().GetBool("output-in-terminal")
+	force, _ := cmd.Flags().GetBool("force")
+
+	// The messages of the steps are only printed in the verbose and debug log levels.
+	restoreLogLevel := terminal.LowerLogLevel()
+	defer restoreLogLevel()
+
+	// Stop fetching when the command is interrupted or times out.
+	ctx, cancel := commandContext(cmd)
+	defer cancel()
+
+	// Get the metadata file content, either from a remote or a local template repository.
+	metadataContent, err := steps.GetRepositoryMetadataContent(ctx, repositorySource, branch, tag, token)
+	if err != nil {
+		return err
+	}
+
+	// Create the metadata struct from raw YAML data.
+	cloneyMetadata, err := steps.ParseRepositoryMetadata(metadataContent, appConfig.SupportedManifestVersions)
+	if err != nil {
+		return err
+	}
+
+	// Find or fetch the base templates, and merge their metadata into the template repository metadata,
+	// so that the inherited variables are included.
+	cloneyMetadata, _, cleanupBases, err := steps.ResolveExtends(ctx, steps.GetLocalRepositoryPath(repositorySource), cloneyMetadata, token)
+	if err != nil {
+		return err
+	}
+	cleanupBases()
+	restoreLogLevel()
+
+	// Generate the variables file content.
+	variablesContent, err := cloneyMetadata.ScaffoldUserVariables(format)
+	if err != nil {
+		terminal.ErrorMessage("Could not generate the variables file", err)
+		return err
+	}
+
+	// If the 'outputInTerminal' flag is set, print the content instead of creating the file.
+	if outputInTerminal {
+		terminal.Messagef("%s", variablesContent)
+		return nil
+	}
+
+	// Calculate the output path. By default, the file is named after the default variables file, with the format extension.
+	if output == "" {
+		output = strings.TrimSuffix(appConfig.DefaultUserVariablesFileName, ".yaml") + "." + format
+	}
+	outputPath, err := steps.CalculatePath(output, "")
+	if err != nil {
+		return err
+	}
+
+	// Do not overwrite an existing variables file unless the 'force' flag is set.
+	if _, err := os.Stat(outputPath); err == nil && !force {
+		err = fmt.Errorf("file '%s' already exists, use the '--force' flag to overwrite it", outputPath)
+		terminal.ErrorMessage("Could not create the variables file", err)
+		return err
+	}
+
+	err = os.WriteFile(outputPath, []byte(variablesContent), 0644)
+	if err != nil {
+		terminal.ErrorMessage("Could not create the variables file", err)
+		return err
+	}
+
+	terminal.OKMessage(fmt.Sprintf("The variables file was created at '%s'", outputPath))
+
+	return nil
+}
+
+// ResetVarsCommandFlags resets the flags of the 'vars' command.
+func ResetVarsCommandFlags(cmd *cobra.Command) {
+	cmd.Flags().Set("branch", "main")
+	// The default branch of the configuration is only used if the flag was not set.
+	cmd.Flags().Lookup("branch").Changed = false
+	cmd.Flags().Set("tag", "")
+	cmd.Flags().Set("token", "")
+	cmd.Flags().Set("timeout", "0s")
+	cmd.Flags().Set("format", metadata.YAML_VARIABLES_FORMAT)
+	cmd.Flags().Set("output", "")
+	cmd.Flags().Set("output-in-terminal", "false")
+	cmd.Flags().Set("force", "false")
+}
+
+// CreateVarsCommand creates the 'vars' command and its respective flags.
+func CreateVarsCommand() *cobra.Command {
+	// varsCmd represents the vars command.
+	// This command is used to generate a variables file from the variables of a Cloney template repository.
+	varsCmd := &cobra.Command{
+		Use:   "vars [local_path OR repository_url]",
+		Short: "Generate a variables file for a Cloney template repository",
+		Long: fmt.Sprintf(`Generate a variables file for a Cloney template repository.
+
+The 'cloney vars' command creates a ready-to-edit variables file from the variables of a Cloney template repository.
+Default values are filled in, required variables get their example values, and descriptions are added as comments.
+
+It can read the variables from a local template repository, or from a remote template repository.
+By default, it reads them from the current directory and creates a file named '%s'.
+Template repositories can also be referenced by the aliases defined in the configuration files.`, appConfig.DefaultUserVariablesFileName),
+		Example: strings.Join([]string{
+			"  vars",
+			"  vars ./path/to/my/template",
+			"  vars https://github.com/username/repository.git",
+			"  vars https://github.com/username/repository.git -f json -o variables.json",
+			"  vars -i",
+		}, "\n"),
+		Aliases:           []string{"variables"},
+		PersistentPreRunE: persistentPreRun,
+		RunE:              varsCmdRun,
+	}
+
+	// Define command-line flags for the 'vars' command.
+	varsCmd.Flags().StringP("branch", "b", "main", "Git branch, if referencing a git repository and no tag is given (defaults to the 'default_branch' of the configuration)")
+	varsCmd.Flags().StringP("tag", "t", "", "Git tag, if referencing a git repository")
+	varsCmd.Flags().StringP("token", "k", "", "Git token, if referencing a private git repository (not recommended)")
+	addTimeoutFlag(varsCmd)
+	varsCmd.Flags().StringP("format", "f", metadata.YAML_VARIABLES_FORMAT, fmt.Sprintf("Format of the variables file (%s)", strings.Join(metadata.SupportedVariablesFormats, ", ")))
+	varsCmd.Flags().StringP("output", "o", "", "Path to save the variables file to")
+	varsCmd.Flags().BoolP("output-in-terminal", "i", false, "Output the variables file content in the terminal instead of creating the file")
+	varsCmd.Flags().Bool("force", false, "Overwrite the variables file if it already exists")
+
+	return varsCmd
+}
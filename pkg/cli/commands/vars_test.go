@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// testVarsCommand represents a command instance used for testing.
+var testVarsCommand = newTestCommand(CreateVarsCommand())
+
+// TestVarsCommandGeneratesYAMLFile tests the "vars" command when the user specifies
+// a local Cloney project directory. It should create a YAML file with the example values.
+func TestVarsCommandGeneratesYAMLFile(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-vars-project")
+
+	// Simulate CLI arguments to specify the project directory and the output file.
+	outputPath := filepath.Join("test-vars-project", "variables.yaml")
+	ResetVarsCommandFlags(testVarsCommand)
+	testVarsCommand.SetArgs([]string{"./test-vars-project", "-o", outputPath})
+
+	// Execute the "vars" command.
+	err := testVarsCommand.Execute()
+
+	// Assert that the "vars" command did not return an error.
+	assert.Nil(err)
+
+	// Assert that the variables file contains the example values.
+	variablesBytes, err := os.ReadFile(outputPath)
+	assert.NoError(err)
+	var parsedVariables map[string]interface{}
+	err = yaml.Unmarshal(variablesBytes, &parsedVariables)
+	assert.NoError(err)
+	assert.Equal("My Bank App", parsedVariables["app_name"])
+	assert.Equal(false, parsedVariables["dark_mode"])
+	assert.Len(parsedVariables["currencies"], 1)
+
+	// Assert that running the command again without the '--force' flag returns an error.
+	testVarsCommand.SetArgs([]string{"./test-vars-project", "-o", outputPath})
+	err = testVarsCommand.Execute()
+	assert.NotNil(err)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-vars-project")
+}
+
+// TestVarsCommandWithUnsupportedFormat tests the "vars" command
+// when the user specifies an unsupported format. It should return an error.
+func TestVarsCommandWithUnsupportedFormat(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// Create a dummy Cloney metadata file in the test project directory.
+	CreateDummyCloneyMetadataFile(assert, "test-vars-project")
+
+	// Simulate CLI arguments with an unsupported format.
+	ResetVarsCommandFlags(testVarsCommand)
+	testVarsCommand.SetArgs([]string{"./test-vars-project", "-f", "xml", "-i"})
+
+	// Execute the "vars" command.
+	err := testVarsCommand.Execute()
+
+	// Assert that the "vars" command returned an error.
+	assert.NotNil(err)
+
+	// Delete the created directory after the test.
+	os.RemoveAll("test-vars-project")
+}
@@ -19,11 +19,11 @@ func CreateVersionCommand() *cobra.Command {
 	// versionCmd represents the version command.
 	// This command is used to print the version of the application.
 	versionCmd := &cobra.Command{
-		Use:              "version",
-		Short:            "Get the current version of Cloney",
-		Long:             "Get the current version of Cloney.",
-		PersistentPreRun: persistentPreRun,
-		Run:              versionCmdRun,
+		Use:               "version",
+		Short:             "Get the current version of Cloney",
+		Long:              "Get the current version of Cloney.",
+		PersistentPreRunE: persistentPreRun,
+		Run:               versionCmdRun,
 	}
 
 	return versionCmd
@@ -12,7 +12,7 @@ import (
 )
 
 // testVersionCmd represents a command instance used for testing.
-var testVersionCmd = CreateVersionCommand()
+var testVersionCmd = newTestCommand(CreateVersionCommand())
 
 // TestVersionCommandOutput tests the output of the version command.
 // It should print the version of the app, the OS and the architecture.
@@ -32,3 +32,31 @@ func TestVersionCommandOutput(t *testing.T) {
 	expectedOutput := fmt.Sprintf("Cloney version %s %s %s\n", appConfig.AppVersion, runtime.GOOS, runtime.GOARCH)
 	assert.Equal(expectedOutput, buffer.String())
 }
+
+// TestVersionCommandWithStructuredOutputFormat tests the version command with the 'json' and 'yaml' output formats.
+// It should return an error, since the command has no result to print, and an unsupported format should be refused too.
+func TestVersionCommandWithStructuredOutputFormat(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+	defer resetOutputFormatFlag(testVersionCmd)
+
+	for _, format := range []string{terminal.JSON_OUTPUT_FORMAT, terminal.YAML_OUTPUT_FORMAT} {
+		// Execute the command.
+		testVersionCmd.SetArgs([]string{"--output-format", format})
+		err := testVersionCmd.Execute()
+
+		// Assert that the command refused the output format.
+		if assert.Error(err) {
+			assert.Contains(err.Error(), "has no result to print")
+		}
+		assert.False(terminal.IsStructuredOutput())
+	}
+
+	// Execute the command with an unsupported output format.
+	testVersionCmd.SetArgs([]string{"--output-format", "xml"})
+	err := testVersionCmd.Execute()
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "unsupported output format 'xml'")
+	}
+	testVersionCmd.SetArgs([]string{})
+}
@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
 	"strings"
+	"syscall"
 
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/cli/commands"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/config"
@@ -41,6 +44,11 @@ func isUnknownFlagError(err error) bool {
 	return strings.Contains(err.Error(), "unknown flag") || strings.Contains(err.Error(), "unknown shorthand flag")
 }
 
+// Helper function to check if the error is related to flags that cannot be used together.
+func isFlagGroupError(err error) bool {
+	return strings.Contains(err.Error(), "if any flags in the group")
+}
+
 // Initialize initializes the CLI.
 func Initialize() {
 	// Create subcommands.
@@ -51,6 +59,8 @@ func Initialize() {
 	versionCmd := commands.CreateVersionCommand()
 	validateCmd := commands.CreateValidateCommand()
 	docsCmd := commands.CreateDocsCommand()
+	lintCmd := commands.CreateLintCommand()
+	varsCmd := commands.CreateVarsCommand()
 
 	// Add subcommands.
 	rootCmd.AddCommand(cloneCmd)
@@ -60,6 +70,11 @@ func Initialize() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(docsCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(varsCmd)
+
+	// Define the flags shared by all commands.
+	commands.AddGlobalFlags(rootCmd)
 
 	// Stylings.
 	cc.Init(&cc.Config{
@@ -71,10 +86,20 @@ func Initialize() {
 		NoExtraNewlines: true,
 	})
 
+	// Cancel the context of the commands on the first interrupt, so they stop and clean up their temporary
+	// and staging directories. A second interrupt terminates the program immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	// Execute the root command.
-	if err := rootCmd.Execute(); err != nil {
-		// Print the error only if it is related to "command not found" or "unknown flag."
-		if isUnknownCommandError(err) || isUnknownFlagError(err) {
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		// Print the error only if it is related to "command not found", "unknown flag" or flags used together.
+		if isUnknownCommandError(err) || isUnknownFlagError(err) || isFlagGroupError(err) {
 			rootCmd.SetOut(rootCmd.ErrOrStderr())
 			rootCmd.Println(err)
 		}
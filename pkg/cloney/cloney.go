@@ -0,0 +1,321 @@
+// Package cloney generates projects from Cloney template repositories, for programs that embed Cloney instead of
+// running the command-line interface.
+//
+// A template is loaded once with 'Load', which finds or fetches it along with its base templates and partial libraries.
+// It can then validate variables and render them into any number of directories. Nothing is printed: progress is
+// reported through the 'OnEvent' callback of the options, and failures are returned as errors.
+//
+//	template, err := cloney.Load(ctx, "https://github.com/username/repository.git", cloney.Options{Token: token})
+//	if err != nil {
+//		return err
+//	}
+//	defer template.Close()
+//	err = template.Render(ctx, variables, "./my-project")
+package cloney
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/git"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/metadata"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
+)
+
+// Constants for the kinds of events reported while loading and rendering a template.
+const (
+	// TEMPLATE_LOADED_EVENT is reported once the template was found or fetched and its metadata was read.
+	TEMPLATE_LOADED_EVENT = "template_loaded"
+
+	// BASES_RESOLVED_EVENT is reported once the base templates were found or fetched, if the template extends any.
+	BASES_RESOLVED_EVENT = "bases_resolved"
+
+	// PARTIALS_FETCHED_EVENT is reported once the partial libraries were fetched, if the template uses any.
+	PARTIALS_FETCHED_EVENT = "partials_fetched"
+
+	// VARIABLES_VALIDATED_EVENT is reported once the variables were validated against the template variables.
+	VARIABLES_VALIDATED_EVENT = "variables_validated"
+
+	// FILE_GENERATED_EVENT is reported for each generated file, with its path relative to the destination directory.
+	FILE_GENERATED_EVENT = "file_generated"
+
+	// RENDERED_EVENT is reported once the generated files were moved into the destination directory.
+	RENDERED_EVENT = "rendered"
+)
+
+// Event is a step reported while loading and rendering a template.
+type Event struct {
+	// Kind is the kind of the event, such as 'FILE_GENERATED_EVENT'.
+	Kind string
+
+	// Message describes the event.
+	Message string
+
+	// Path is the slash-separated path of the generated file, relative to the destination directory, for file events.
+	Path string
+}
+
+// Options are the options used to load and render a template.
+// Unlike the command-line interface, no option is read from the environment or from the configuration files.
+type Options struct {
+	// Branch is the branch of a remote template. If neither a branch nor a tag is defined, the default branch is used.
+	Branch string
+
+	// Tag is the tag of a remote template.
+	Tag string
+
+	// Token is the git token used to fetch private templates, base templates and partial libraries.
+	Token string
+
+	// DefaultBranch is the branch of the remote template, base templates and partial libraries that define neither
+	// a branch nor a tag. If empty, 'main' is used.
+	DefaultBranch string
+
+	// CacheDir is the directory holding the fetched repositories until the template is closed.
+	// If empty, the default directory for temporary files is used.
+	CacheDir string
+
+	// Force replaces the destination directory if it already exists and is not empty.
+	Force bool
+
+	// Workers is the maximum number of files rendered concurrently. If zero, one worker per CPU is used.
+	Workers int
+
+	// OnEvent is called for each step of loading and rendering, if it is not nil.
+	OnEvent func(Event)
+}
+
+// fetchOptions returns the options used to fetch the repositories.
+func (o Options) fetchOptions() FetchOptions {
+	return FetchOptions{Token: o.Token, DefaultBranch: o.DefaultBranch, CacheDir: o.CacheDir}
+}
+
+// Template is a loaded template repository, ready to be rendered.
+// It must be closed once it is no longer needed, to delete the temporary directories holding fetched repositories.
+type Template struct {
+	// Source is the git repository URL or the local path the template was loaded from.
+	Source string
+
+	// Metadata is the metadata of the template, merged with the metadata of its base templates.
+	Metadata *metadata.CloneyMetadata
+
+	// path is the directory holding the files of the template.
+	path string
+
+	// bases are the base templates, from the closest to the deepest.
+	bases []Base
+
+	// partials are the partial libraries of the template.
+	partials []templates.PartialLibrary
+
+	// ignorePaths are the patterns of the files that are not generated.
+	ignorePaths []string
+
+	// options are the options the template was loaded with.
+	options Options
+
+	// cleanups delete the temporary directories.
+	cleanups []func()
+}
+
+// Load finds a local template or fetches a remote template, along with its base templates and partial libraries,
+// and reads its metadata. 'source' is a git repository URL or a local path.
+// Fetching stops once the context is cancelled.
+func Load(ctx context.Context, source string, options Options) (*Template, error) {
+	t := &Template{Source: source, options: options}
+	fetchOptions := options.fetchOptions()
+
+	// Find or fetch the template.
+	if git.MatchesGitRepositoryURL(source) {
+		repository := fetchOptions.repository(source, options.Branch, options.Tag)
+		if err := repository.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid template repository '%s': %w", source, err)
+		}
+	} else {
+		absolutePath, err := filepath.Abs(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template path '%s': %w", source, err)
+		}
+		source = absolutePath
+	}
+	templatePath, _, temporaryDir, err := fetchTemplate(ctx, source, options.Branch, options.Tag, "", fetchOptions)
+	if temporaryDir != "" {
+		t.cleanups = append(t.cleanups, func() { os.RemoveAll(temporaryDir) })
+	}
+	if err != nil {
+		t.Close()
+		return nil, fmt.Errorf("could not load the template '%s': %w", t.Source, err)
+	}
+	t.path = templatePath
+
+	cloneyMetadata, err := readMetadata(templatePath)
+	if err != nil {
+		t.Close()
+		return nil, fmt.Errorf("could not load the template '%s': %w", t.Source, err)
+	}
+	t.emit(Event{Kind: TEMPLATE_LOADED_EVENT, Message: fmt.Sprintf("The template '%s' was loaded", t.Source)})
+
+	// Find or fetch the base templates, and merge their metadata into the template metadata.
+	// Remote templates can only extend remote base templates.
+	extendsDir := templatePath
+	if temporaryDir != "" {
+		extendsDir = ""
+	}
+	cloneyMetadata, bases, cleanupBases, err := ResolveExtends(ctx, extendsDir, cloneyMetadata, fetchOptions)
+	if err != nil {
+		t.Close()
+		return nil, fmt.Errorf("could not resolve the base templates: %w", err)
+	}
+	t.cleanups = append(t.cleanups, cleanupBases)
+	t.Metadata, t.bases = cloneyMetadata, bases
+	if len(bases) > 0 {
+		t.emit(Event{Kind: BASES_RESOLVED_EVENT, Message: fmt.Sprintf("%d base templates were resolved", len(bases))})
+	}
+
+	t.ignorePaths, err = IgnorePaths(templatePath, cloneyMetadata)
+	if err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	// Fetch the partial libraries and find the partials directories.
+	partials, cleanupPartials, err := FetchPartials(ctx, templatePath, cloneyMetadata, bases, fetchOptions)
+	if err != nil {
+		t.Close()
+		return nil, err
+	}
+	t.cleanups = append(t.cleanups, cleanupPartials)
+	t.partials = partials
+	if len(cloneyMetadata.Configuration.Libraries) > 0 {
+		t.emit(Event{Kind: PARTIALS_FETCHED_EVENT, Message: "The partial libraries were fetched"})
+	}
+
+	return t, nil
+}
+
+// Close deletes the temporary directories holding the fetched repositories. It is safe to call it more than once.
+func (t *Template) Close() error {
+	for _, cleanup := range t.cleanups {
+		cleanup()
+	}
+	t.cleanups = nil
+	return nil
+}
+
+// Validate checks that the variables match the template variables: required variables must be defined,
+// and values must have the type of the examples of the metadata file. The variables are not modified.
+func (t *Template) Validate(variables map[string]interface{}) error {
+	_, err := t.matchVariables(variables)
+	return err
+}
+
+// Render validates the variables and renders the template into the destination directory.
+// Default values are used for the variables that are not defined. The files are generated in a staging directory
+// next to the destination directory, which is only replaced once all files were generated, so a failure or
+// a cancelled context never leaves a half-generated directory behind.
+// The destination directory must be empty or not exist, unless the 'Force' option is set.
+func (t *Template) Render(ctx context.Context, variables map[string]interface{}, dest string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	variablesMap, err := t.matchVariables(variables)
+	if err != nil {
+		return err
+	}
+	t.emit(Event{Kind: VARIABLES_VALIDATED_EVENT, Message: "The variables match the template variables"})
+
+	// Refuse to replace a non-empty directory unless the 'Force' option is set.
+	dest, err = filepath.Abs(dest)
+	if err != nil {
+		return fmt.Errorf("invalid destination directory '%s': %w", dest, err)
+	}
+	empty, err := templates.IsDirectoryEmpty(dest)
+	if err != nil {
+		return fmt.Errorf("could not read the directory '%s': %w", dest, err)
+	}
+	if !empty && !t.options.Force {
+		return fmt.Errorf("directory '%s' already exists and is not empty, use the 'Force' option to replace it", dest)
+	}
+
+	// Render the files in memory, without printing anything.
+	filler := templates.NewTemplateFiller(variablesMap)
+	filler.RenderOptions = RenderOptions(t.Metadata.Configuration, t.partials, t.bases)
+	// The file modes were already validated when parsing the metadata file.
+	filler.FileModes, _ = t.Metadata.Configuration.FileModes()
+	filler.Workers = t.options.Workers
+	filler.Silent = true
+	rendered := templates.NewMemoryFS()
+	if err := filler.Render(ctx, templates.NewDiskFS(t.path), t.ignorePaths, rendered); err != nil {
+		return fmt.Errorf("could not render the template: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Files starting with the Ignore Prefix, or inside directories starting with it, and files created with 'toFile'
+	// that match the ignore paths are not generated.
+	matcher := templates.NewIgnoreMatcher(t.ignorePaths)
+	for _, name := range rendered.Names() {
+		if matcher.Match(name, false) || hasIgnorePrefix(name) {
+			rendered.Remove(name)
+		}
+	}
+
+	// Write the files into a staging directory, and move it into place.
+	staging, err := templates.NewStagingDirectory(dest)
+	if err != nil {
+		return err
+	}
+	defer staging.Cleanup()
+	if err := rendered.CommitTo(templates.NewDiskFS(staging.Path)); err != nil {
+		return fmt.Errorf("could not write the generated files: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := staging.Commit(); err != nil {
+		return err
+	}
+
+	for _, name := range rendered.Names() {
+		t.emit(Event{Kind: FILE_GENERATED_EVENT, Message: fmt.Sprintf("The file '%s' was generated", name), Path: name})
+	}
+	t.emit(Event{Kind: RENDERED_EVENT, Message: fmt.Sprintf("The template was rendered into '%s'", dest)})
+
+	return nil
+}
+
+// matchVariables returns a copy of the variables, with the default values of the variables that are not defined,
+// or an error if they do not match the template variables.
+func (t *Template) matchVariables(variables map[string]interface{}) (map[string]interface{}, error) {
+	variablesMap := maps.Clone(variables)
+	if variablesMap == nil {
+		variablesMap = make(map[string]interface{})
+	}
+	if _, err := t.Metadata.MatchUserVariables(variablesMap); err != nil {
+		return nil, fmt.Errorf("invalid variables: %w", err)
+	}
+	return variablesMap, nil
+}
+
+// hasIgnorePrefix returns true if a file or one of its parent directories starts with the Ignore Prefix.
+func hasIgnorePrefix(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, appConfig.IgnorePrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// emit reports an event to the 'OnEvent' callback of the options, if any.
+func (t *Template) emit(event Event) {
+	if t.options.OnEvent != nil {
+		t.options.OnEvent(event)
+	}
+}
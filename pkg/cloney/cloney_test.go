@@ -0,0 +1,190 @@
+package cloney
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/metadata"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeDummyTemplate creates a local template with a required and an optional variable,
+// a template file, an ignored file and a partial, in a temporary directory deleted after the test.
+func writeDummyTemplate(t *testing.T) string {
+	assert := assert.New(t)
+	templateDir := t.TempDir()
+	files := map[string]string{
+		".cloney.yaml": `
+manifest_version: v1
+name: Library
+template_version: 0.0.0
+variables:
+  - name: app_name
+    example: MyApp
+  - name: port
+    default: 8080
+    example: 8080
+`,
+		"README.md":                   `# {{ .app_name }} {{ include "header" . }}`,
+		"config/app.yaml":             "port: {{ .port }}",
+		"__notes.md":                  "Not generated.",
+		".cloney/partials/header.txt": `{{ define "header" }}on port {{ .port }}{{ end }}`,
+	}
+	for name, content := range files {
+		filePath := filepath.Join(templateDir, filepath.FromSlash(name))
+		assert.Nil(os.MkdirAll(filepath.Dir(filePath), os.ModePerm))
+		assert.Nil(os.WriteFile(filePath, []byte(content), 0644))
+	}
+	return templateDir
+}
+
+// TestLoadAndRender tests loading a local template and rendering it with events.
+// It should generate the template files with the default values, and report each generated file.
+func TestLoadAndRender(t *testing.T) {
+	assert := assert.New(t)
+	templateDir := writeDummyTemplate(t)
+	dest := filepath.Join(t.TempDir(), "output")
+
+	var generated []string
+	template, err := Load(context.Background(), templateDir, Options{OnEvent: func(event Event) {
+		if event.Kind == FILE_GENERATED_EVENT {
+			generated = append(generated, event.Path)
+		}
+	}})
+	assert.Nil(err)
+	defer template.Close()
+	assert.Equal("Library", template.Metadata.Name)
+
+	variables := map[string]interface{}{"app_name": "MyApp"}
+	err = template.Render(context.Background(), variables, dest)
+	assert.Nil(err)
+
+	// The variables given by the caller are not modified.
+	assert.Equal(map[string]interface{}{"app_name": "MyApp"}, variables)
+
+	content, err := os.ReadFile(filepath.Join(dest, "README.md"))
+	assert.Nil(err)
+	assert.Equal("# MyApp on port 8080", string(content))
+	content, err = os.ReadFile(filepath.Join(dest, "config", "app.yaml"))
+	assert.Nil(err)
+	assert.Equal("port: 8080", string(content))
+	for _, name := range []string{"__notes.md", ".cloney.yaml", ".cloney"} {
+		_, err = os.Stat(filepath.Join(dest, name))
+		assert.True(os.IsNotExist(err), name)
+	}
+	assert.Equal([]string{"README.md", "config/app.yaml"}, generated)
+}
+
+// TestValidate tests validating variables against the template variables.
+// It should refuse missing required variables and values of the wrong type.
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	templateDir := writeDummyTemplate(t)
+
+	template, err := Load(context.Background(), templateDir, Options{})
+	assert.Nil(err)
+	defer template.Close()
+
+	assert.Nil(template.Validate(map[string]interface{}{"app_name": "MyApp"}))
+	assert.NotNil(template.Validate(map[string]interface{}{}))
+	assert.NotNil(template.Validate(map[string]interface{}{"app_name": "MyApp", "port": "not a port"}))
+}
+
+// TestRenderRefusesNonEmptyDirectory tests rendering into a directory that is not empty, and with a cancelled context.
+// It should return an error and keep the existing files, unless the 'Force' option is set.
+func TestRenderRefusesNonEmptyDirectory(t *testing.T) {
+	assert := assert.New(t)
+	templateDir := writeDummyTemplate(t)
+	dest := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(dest, "existing.txt"), []byte("existing"), 0644))
+	variables := map[string]interface{}{"app_name": "MyApp"}
+
+	template, err := Load(context.Background(), templateDir, Options{})
+	assert.Nil(err)
+	defer template.Close()
+	assert.NotNil(template.Render(context.Background(), variables, dest))
+	_, err = os.Stat(filepath.Join(dest, "existing.txt"))
+	assert.Nil(err)
+
+	forced, err := Load(context.Background(), templateDir, Options{Force: true})
+	assert.Nil(err)
+	defer forced.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(forced.Render(ctx, variables, dest), context.Canceled)
+	_, err = os.Stat(filepath.Join(dest, "existing.txt"))
+	assert.Nil(err)
+
+	assert.Nil(forced.Render(context.Background(), variables, dest))
+	_, err = os.Stat(filepath.Join(dest, "existing.txt"))
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dest, "README.md"))
+	assert.Nil(err)
+}
+
+// TestResolveExtendsRefusesLocalBaseTemplateOfRemoteTemplate tests resolving a local base template for a remote template,
+// whose directory is not given. It should be refused, even if the base template path is absolute.
+func TestResolveExtendsRefusesLocalBaseTemplateOfRemoteTemplate(t *testing.T) {
+	assert := assert.New(t)
+	baseDir := writeDummyTemplate(t)
+
+	for _, source := range []string{"../base", baseDir} {
+		templateMetadata := &metadata.CloneyMetadata{Extends: &metadata.CloneyMetadataExtends{Source: source}}
+		_, _, _, err := ResolveExtends(context.Background(), "", templateMetadata, FetchOptions{})
+		if assert.Error(err, source) {
+			assert.Contains(err.Error(), "remote templates can only extend remote base templates")
+		}
+	}
+
+	// The same base template is found for a local template.
+	templateMetadata := &metadata.CloneyMetadata{Extends: &metadata.CloneyMetadataExtends{Source: baseDir}}
+	merged, bases, cleanup, err := ResolveExtends(context.Background(), t.TempDir(), templateMetadata, FetchOptions{})
+	assert.NoError(err)
+	defer cleanup()
+	assert.Len(bases, 1)
+	assert.Equal("Library", bases[0].Metadata.Name)
+	assert.NotNil(merged)
+}
+
+// TestRenderIsSilent tests rendering while a command is running with the debug log level.
+// Nothing should be printed, since the library reports its progress with events.
+func TestRenderIsSilent(t *testing.T) {
+	assert := assert.New(t)
+	templateDir := writeDummyTemplate(t)
+
+	var buffer bytes.Buffer
+	terminal.SetCmd(&cobra.Command{})
+	terminal.SetLogLevel(terminal.DEBUG_LOG_LEVEL)
+	terminal.SetTestMode(&buffer)
+	defer func() {
+		terminal.SetCmd(nil)
+		terminal.SetLogLevel(terminal.NORMAL_LOG_LEVEL)
+		terminal.SetTestMode(nil)
+	}()
+
+	template, err := Load(context.Background(), templateDir, Options{})
+	assert.Nil(err)
+	defer template.Close()
+	assert.Nil(template.Render(context.Background(), map[string]interface{}{"app_name": "MyApp"}, filepath.Join(t.TempDir(), "output")))
+	assert.Empty(buffer.String())
+}
+
+// TestFetchOptionsRepository tests the repositories of the fetch options.
+// The default branch of the options should be used, or 'main', unless a branch or a tag is defined.
+func TestFetchOptionsRepository(t *testing.T) {
+	assert := assert.New(t)
+	url := "https://github.com/username/repository.git"
+
+	assert.Equal("main", FetchOptions{}.repository(url, "", "").Branch)
+	assert.Equal("develop", FetchOptions{DefaultBranch: "develop"}.repository(url, "", "").Branch)
+	assert.Equal("feature", FetchOptions{DefaultBranch: "develop"}.repository(url, "feature", "").Branch)
+	repository := FetchOptions{DefaultBranch: "develop"}.repository(url, "", "v1.0.0")
+	assert.Equal("", repository.Branch)
+	assert.Equal("v1.0.0", repository.Tag)
+}
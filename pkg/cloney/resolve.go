@@ -0,0 +1,484 @@
+package cloney
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/config"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/git"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/metadata"
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/templates"
+)
+
+// This file defines the steps shared by the library and the commands to find, fetch and combine template repositories.
+// They never print: errors are returned with their context, and the commands decide how to report them.
+// Fetching stops once the context is cancelled, and the temporary directories are deleted as on any other error.
+
+// Base is a base template extended by a template repository, found on disk or fetched into a temporary directory.
+// Composed templates are also represented as bases of the empty directory they are generated into.
+type Base struct {
+	// Path is the directory of the base template.
+	Path string
+
+	// Metadata is the metadata of the base template. Its ignore paths include the patterns of its '.cloneyignore'
+	// file and its partials directory, so that they apply to its files once inherited.
+	Metadata *metadata.CloneyMetadata
+
+	// IgnorePaths are the patterns of the files hidden from the layers above. They are only used for composed templates,
+	// whose ignore paths apply to their own files, while the ignore paths of base templates are inherited.
+	IgnorePaths []string
+}
+
+// maxExtendsDepth is the maximum number of base templates in an inheritance chain.
+const maxExtendsDepth = 10
+
+// removeAll returns a function that deletes temporary directories.
+func removeAll(temporaryDirs *[]string) func() {
+	return func() {
+		for _, temporaryDir := range *temporaryDirs {
+			os.RemoveAll(temporaryDir)
+		}
+	}
+}
+
+// appConfig is the application configuration. The library never reads the configuration files, so that it does not
+// depend on the user running it: their settings are given with the options instead.
+var appConfig = config.DefaultAppConfig()
+
+// FetchOptions are the options used to fetch remote templates, base templates and partial libraries.
+type FetchOptions struct {
+	// Token is the git token used to fetch private repositories.
+	Token string
+
+	// DefaultBranch is the branch of the repositories that define neither a branch nor a tag. If empty, 'main' is used.
+	DefaultBranch string
+
+	// CacheDir is the directory holding the fetched repositories until they are no longer needed.
+	// If empty, the default directory for temporary files is used.
+	CacheDir string
+}
+
+// repository returns a git repository, authenticated if a token is provided.
+// The default branch is used if neither a branch nor a tag is defined.
+func (o FetchOptions) repository(url, branch, tag string) *git.GitRepository {
+	repository := &git.GitRepository{URL: url, Branch: branch, Tag: tag}
+	if repository.Branch == "" && repository.Tag == "" {
+		repository.Branch = o.DefaultBranch
+		if repository.Branch == "" {
+			repository.Branch = appConfig.DefaultBranch
+		}
+	}
+	if o.Token != "" {
+		repository.AuthenticateWithToken(o.Token)
+	}
+	return repository
+}
+
+// fetchTemplate finds a local template or fetches a remote template into a temporary directory.
+// Local templates are relative to 'relativeTo'. Remote templates use the default branch if neither a branch nor a tag is defined.
+// It returns the directory of the template, a key identifying it and the temporary directory, if any,
+// which must be deleted by the caller, even on error.
+func fetchTemplate(ctx context.Context, source, branch, tag, relativeTo string, fetchOptions FetchOptions) (string, string, string, error) {
+	if !git.MatchesGitRepositoryURL(source) {
+		if relativeTo == "" && !filepath.IsAbs(source) {
+			return "", "", "", fmt.Errorf("the local template '%s' cannot be resolved without a directory it is relative to", source)
+		}
+		templatePath := source
+		if !filepath.IsAbs(templatePath) {
+			templatePath = filepath.Join(relativeTo, templatePath)
+		}
+		templatePath = filepath.Clean(templatePath)
+		return templatePath, templatePath, "", nil
+	}
+
+	repository := fetchOptions.repository(source, branch, tag)
+	identifier := fmt.Sprintf("%s@%s%s", repository.URL, repository.Branch, repository.Tag)
+
+	temporaryDir, err := config.CreateTemporaryDirectoryIn(fetchOptions.CacheDir, "cloney-template-*")
+	if err != nil {
+		return "", "", "", fmt.Errorf("could not create a temporary directory: %w", err)
+	}
+	if err := repository.Clone(ctx, temporaryDir); err != nil {
+		return "", "", temporaryDir, fmt.Errorf("could not fetch the template repository: %w", err)
+	}
+	return temporaryDir, identifier, temporaryDir, nil
+}
+
+// readMetadata reads and parses the metadata file of the template in 'templatePath'.
+func readMetadata(templatePath string) (*metadata.CloneyMetadata, error) {
+	metadataBytes, err := os.ReadFile(filepath.Join(templatePath, appConfig.MetadataFileName))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the \"%s\" metadata file: %w", appConfig.MetadataFileName, err)
+	}
+	templateMetadata, err := metadata.NewCloneyMetadataFromRawYAML(string(metadataBytes), appConfig.SupportedManifestVersions)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the metadata: %w", err)
+	}
+	return templateMetadata, nil
+}
+
+// loadTemplate finds a local template or fetches a remote template into a temporary directory, and reads its metadata.
+// Local templates are relative to 'relativeTo'. The ignore paths of the metadata include the patterns of the
+// '.cloneyignore' file and the partials directory of the template, so that they apply to its files once layered with others.
+// It returns the template, a key identifying it and the temporary directory, if any, which must be deleted by the caller.
+func loadTemplate(ctx context.Context, source, branch, tag, relativeTo string, fetchOptions FetchOptions) (Base, string, string, error) {
+	// Find or fetch the template.
+	templatePath, identifier, temporaryDir, err := fetchTemplate(ctx, source, branch, tag, relativeTo, fetchOptions)
+	if err != nil {
+		return Base{}, "", temporaryDir, err
+	}
+
+	// Read and parse the metadata of the template.
+	templateMetadata, err := readMetadata(templatePath)
+	if err != nil {
+		return Base{}, "", temporaryDir, err
+	}
+
+	// The '.cloneyignore' file and the partials directory of the template apply to its files once layered.
+	ignoreFilePatterns, err := templates.ReadIgnoreFile(filepath.Join(templatePath, appConfig.IgnoreFileName))
+	if err != nil {
+		return Base{}, "", temporaryDir, fmt.Errorf("could not read the \"%s\" file: %w", appConfig.IgnoreFileName, err)
+	}
+	templateMetadata.Configuration.IgnorePaths = append(templateMetadata.Configuration.IgnorePaths, ignoreFilePatterns...)
+	templateMetadata.Configuration.IgnorePaths = append(templateMetadata.Configuration.IgnorePaths, "/"+PartialsDirectory(templateMetadata)+"/")
+
+	return Base{Path: templatePath, Metadata: templateMetadata}, identifier, temporaryDir, nil
+}
+
+// IgnorePaths returns the ignore patterns of a template repository: the paths known to be irrelevant to templates,
+// the 'ignore_paths' of the metadata file and the patterns of the '.cloneyignore' file, if it exists, in this order.
+// Since the last matching pattern wins, the '.cloneyignore' file can re-include paths with '!' patterns.
+func IgnorePaths(src string, cloneyMetadata *metadata.CloneyMetadata) ([]string, error) {
+	var ignorePaths []string
+	ignorePaths = append(ignorePaths, appConfig.KnownIgnorePaths...)
+	ignorePaths = append(ignorePaths, cloneyMetadata.Configuration.IgnorePaths...)
+
+	ignoreFilePatterns, err := templates.ReadIgnoreFile(filepath.Join(src, appConfig.IgnoreFileName))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the \"%s\" file: %w", appConfig.IgnoreFileName, err)
+	}
+	ignorePaths = append(ignorePaths, ignoreFilePatterns...)
+
+	// The partials directory is never generated, so it comes last and cannot be re-included.
+	ignorePaths = append(ignorePaths, "/"+PartialsDirectory(cloneyMetadata)+"/")
+
+	return ignorePaths, nil
+}
+
+// PartialsDirectory returns the slash-separated partials directory of a template repository, relative to its root.
+func PartialsDirectory(cloneyMetadata *metadata.CloneyMetadata) string {
+	if cloneyMetadata.Configuration.PartialsDir == "" {
+		return appConfig.DefaultPartialsDirectoryName
+	}
+	return path.Clean(filepath.ToSlash(cloneyMetadata.Configuration.PartialsDir))
+}
+
+// ResolveExtends finds or fetches the base templates extended by a template repository, recursively, and returns them
+// from the closest to the deepest, along with the metadata of the template repository merged with theirs.
+// Local base templates are relative to the directory of the template that extends them. Remote templates, including
+// remote base templates, can only extend remote base templates, so that they never read files outside their repository:
+// 'src' must be empty for a remote template repository, even if its files were fetched.
+// The returned function deletes the temporary directories and must be called once the base templates are no longer needed.
+func ResolveExtends(ctx context.Context, src string, cloneyMetadata *metadata.CloneyMetadata, fetchOptions FetchOptions) (*metadata.CloneyMetadata, []Base, func(), error) {
+	merged, bases, temporaryDirs, err := resolveExtends(ctx, src, cloneyMetadata, fetchOptions)
+	cleanup := removeAll(&temporaryDirs)
+	if err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+	return merged, bases, cleanup, nil
+}
+
+// resolveExtends implements 'ResolveExtends'. It returns the temporary directories, even on error.
+func resolveExtends(ctx context.Context, src string, cloneyMetadata *metadata.CloneyMetadata, fetchOptions FetchOptions) (*metadata.CloneyMetadata, []Base, []string, error) {
+	var bases []Base
+	var temporaryDirs []string
+	visited := make(map[string]bool)
+	current, currentDir := cloneyMetadata, src
+	for current.Extends != nil {
+		extends := current.Extends
+		if len(bases) == maxExtendsDepth {
+			return nil, nil, temporaryDirs, fmt.Errorf("more than %d base templates are extended, the inheritance chain is too deep", maxExtendsDepth)
+		}
+		if currentDir == "" && !extends.IsRemote() {
+			return nil, nil, temporaryDirs, fmt.Errorf("base template '%s': remote templates can only extend remote base templates", extends.Source)
+		}
+
+		base, identifier, temporaryDir, err := loadTemplate(ctx, extends.Source, extends.Branch, extends.Tag, currentDir, fetchOptions)
+		if temporaryDir != "" {
+			temporaryDirs = append(temporaryDirs, temporaryDir)
+		}
+		if err != nil {
+			return nil, nil, temporaryDirs, fmt.Errorf("base template '%s': %w", extends.Source, err)
+		}
+		if visited[identifier] {
+			return nil, nil, temporaryDirs, fmt.Errorf("the base template '%s' is extended more than once, the inheritance chain has a cycle", extends.Source)
+		}
+		visited[identifier] = true
+
+		// Fetched base templates are remote, so their local base templates are refused.
+		bases = append(bases, base)
+		current, currentDir = base.Metadata, base.Path
+		if temporaryDir != "" {
+			currentDir = ""
+		}
+	}
+
+	// Merge the metadata, from the deepest base template to the template repository.
+	if len(bases) == 0 {
+		return cloneyMetadata, nil, temporaryDirs, nil
+	}
+	merged := bases[len(bases)-1].Metadata
+	for index := len(bases) - 2; index >= 0; index-- {
+		merged = bases[index].Metadata.Extend(merged)
+	}
+	return cloneyMetadata.Extend(merged), bases, temporaryDirs, nil
+}
+
+// ComposeTemplates finds or fetches several templates, with their base templates, to generate them into the same directory.
+// Local templates are relative to 'relativeTo'. It returns the metadata of all templates composed in order, see
+// 'CloneyMetadata.Compose', and the templates with their base templates as layers, from the last template declared to the
+// first one, so that they can be used as the base templates of an empty directory.
+// Files generated by several templates are refused with the 'error' collision strategy, and the last template declared
+// wins with the 'order' strategy. Templates with different action delimiters cannot be composed, and the ignore paths,
+// copy-only, render-only, mode and delimiter override patterns of each template only apply to its own files.
+// The returned function deletes the temporary directories and must be called once the templates are no longer needed.
+func ComposeTemplates(ctx context.Context, compositionTemplates []metadata.CompositionTemplate, collisionStrategy, relativeTo string, fetchOptions FetchOptions) (*metadata.CloneyMetadata, []Base, func(), error) {
+	var temporaryDirs []string
+	cleanup := removeAll(&temporaryDirs)
+	fail := func(err error) (*metadata.CloneyMetadata, []Base, func(), error) {
+		cleanup()
+		return nil, nil, nil, err
+	}
+
+	var composed *metadata.CloneyMetadata
+	var layers []Base
+	var configurations []metadata.CloneyMetadataConfiguration
+	filesByPath := make(map[string]string)
+	ownersByPath := make(map[string]int)
+	var collisions []string
+	for index, compositionTemplate := range compositionTemplates {
+		// Find or fetch the template and its base templates.
+		composedTemplate, _, temporaryDir, err := loadTemplate(ctx, compositionTemplate.Source, compositionTemplate.Branch, compositionTemplate.Tag, relativeTo, fetchOptions)
+		if temporaryDir != "" {
+			temporaryDirs = append(temporaryDirs, temporaryDir)
+		}
+		if err != nil {
+			return fail(fmt.Errorf("could not load the template '%s': %w", compositionTemplate.Source, err))
+		}
+		templateDir := composedTemplate.Path
+		if temporaryDir != "" {
+			templateDir = ""
+		}
+		templateMetadata, bases, baseDirs, err := resolveExtends(ctx, templateDir, composedTemplate.Metadata, fetchOptions)
+		temporaryDirs = append(temporaryDirs, baseDirs...)
+		if err != nil {
+			return fail(fmt.Errorf("could not resolve the base templates of '%s': %w", compositionTemplate.Source, err))
+		}
+		templateLayers := append([]Base{composedTemplate}, bases...)
+
+		// Compose the variables and the configuration with the previous templates.
+		if composed == nil {
+			composed = templateMetadata
+		} else if err = checkDelimiters(composed, templateMetadata); err != nil {
+			return fail(fmt.Errorf("the templates cannot be composed: %w", err))
+		} else if composed, err = templateMetadata.Compose(composed); err != nil {
+			return fail(fmt.Errorf("the templates cannot be composed: %w", err))
+		}
+		configurations = append(configurations, templateMetadata.Configuration)
+
+		// Find the files generated by more than one template.
+		var templateFS []fs.FS
+		for _, layer := range templateLayers {
+			templateFS = append(templateFS, os.DirFS(layer.Path))
+		}
+		var ignorePaths []string
+		ignorePaths = append(ignorePaths, appConfig.KnownIgnorePaths...)
+		ignorePaths = append(ignorePaths, templateMetadata.Configuration.IgnorePaths...)
+		filePaths, err := templates.ListFiles(templates.NewLayeredFS(templateFS...), ignorePaths)
+		if err != nil {
+			return fail(fmt.Errorf("could not list the files of the template '%s': %w", compositionTemplate.Source, err))
+		}
+		for _, filePath := range filePaths {
+			ownersByPath[filePath] = index
+
+			// Files starting with the Ignore Prefix are not generated, so they cannot collide.
+			if strings.HasPrefix(path.Base(filePath), appConfig.IgnorePrefix) {
+				continue
+			}
+			if previousSource, ok := filesByPath[filePath]; ok {
+				collisions = append(collisions, fmt.Sprintf("%s (%s, %s)", filePath, previousSource, compositionTemplate.Source))
+			}
+			filesByPath[filePath] = compositionTemplate.Source
+		}
+
+		// The layers of the last template declared come first, so that its files win.
+		// Each layer hides the files ignored by its template, so that they do not hide the files of the previous templates.
+		for layerIndex := range templateLayers {
+			templateLayers[layerIndex].IgnorePaths = templateMetadata.Configuration.IgnorePaths
+		}
+		layers = append(templateLayers, layers...)
+	}
+
+	if len(collisions) > 0 && collisionStrategy != metadata.ORDER_COLLISION_STRATEGY {
+		return fail(fmt.Errorf(
+			"several templates generate the same files, use the 'order' collision strategy to keep the files of the last template declared:\n  %s",
+			strings.Join(collisions, "\n  "),
+		))
+	}
+
+	scopeConfiguration(&composed.Configuration, configurations, ownersByPath)
+	return composed, layers, cleanup, nil
+}
+
+// checkDelimiters checks that a template has the same action delimiters as the templates composed before it,
+// since the partials of all templates are parsed together.
+func checkDelimiters(composed, templateMetadata *metadata.CloneyMetadata) error {
+	composedDelimiters := RenderOptions(composed.Configuration, nil, nil).DelimitersFor("")
+	templateDelimiters := RenderOptions(templateMetadata.Configuration, nil, nil).DelimitersFor("")
+	if composedDelimiters != templateDelimiters {
+		return fmt.Errorf(
+			"template '%s' uses the delimiters '%s' and '%s', but template '%s' uses '%s' and '%s'",
+			composed.Name, composedDelimiters.Left, composedDelimiters.Right,
+			templateMetadata.Name, templateDelimiters.Left, templateDelimiters.Right,
+		)
+	}
+	return nil
+}
+
+// scopeConfiguration replaces the file patterns of a composed configuration with the files they match in the template
+// generating each file, given by its index in 'configurations', so that the patterns of a template never apply to the
+// files of the others. The ignore paths are applied by the layers of each template instead.
+func scopeConfiguration(composed *metadata.CloneyMetadataConfiguration, configurations []metadata.CloneyMetadataConfiguration, ownersByPath map[string]int) {
+	composed.IgnorePaths = nil
+	composed.CopyOnly = nil
+	composed.RenderOnly = nil
+	composed.Modes = nil
+	composed.DelimiterOverrides = nil
+
+	filePaths := make([]string, 0, len(ownersByPath))
+	for filePath := range ownersByPath {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	for _, filePath := range filePaths {
+		configuration := configurations[ownersByPath[filePath]]
+		pattern := templates.AnchoredPattern(filePath)
+		if templates.MatchesAnyGlob(filePath, configuration.CopyOnly) {
+			composed.CopyOnly = append(composed.CopyOnly, pattern)
+		}
+		if templates.MatchesAnyGlob(filePath, configuration.RenderOnly) {
+			composed.RenderOnly = append(composed.RenderOnly, pattern)
+		}
+		if modePattern, ok := templates.MostSpecificPattern(filePath, mapKeys(configuration.Modes)); ok {
+			if composed.Modes == nil {
+				composed.Modes = make(map[string]string)
+			}
+			composed.Modes[pattern] = configuration.Modes[modePattern]
+		}
+		if delimitersPattern, ok := templates.MostSpecificPattern(filePath, mapKeys(configuration.DelimiterOverrides)); ok {
+			if composed.DelimiterOverrides == nil {
+				composed.DelimiterOverrides = make(map[string][]string)
+			}
+			composed.DelimiterOverrides[pattern] = configuration.DelimiterOverrides[delimitersPattern]
+		}
+	}
+}
+
+// mapKeys returns the keys of a map.
+func mapKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// FetchPartials returns the partial libraries of a template repository: the remote libraries of the metadata file,
+// fetched into temporary directories, followed by the partials directories of the base templates, from the deepest,
+// and the partials directory of the template repository, if they exist, so that each one can override the previous ones.
+// The returned function deletes the temporary directories and must be called once the partials are no longer needed.
+func FetchPartials(ctx context.Context, src string, cloneyMetadata *metadata.CloneyMetadata, bases []Base, fetchOptions FetchOptions) ([]templates.PartialLibrary, func(), error) {
+	var partials []templates.PartialLibrary
+	var temporaryDirs []string
+	cleanup := removeAll(&temporaryDirs)
+
+	for _, library := range cloneyMetadata.Configuration.Libraries {
+		repository := fetchOptions.repository(library.URL, library.Branch, library.Tag)
+
+		temporaryDir, err := config.CreateTemporaryDirectoryIn(fetchOptions.CacheDir, "cloney-library-*")
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("could not create a temporary directory for the partial libraries: %w", err)
+		}
+		temporaryDirs = append(temporaryDirs, temporaryDir)
+
+		err = repository.Clone(ctx, temporaryDir)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("could not fetch the partial library '%s': %w", library.URL, err)
+		}
+
+		reference := repository.Branch
+		if repository.Tag != "" {
+			reference = repository.Tag
+		}
+		partials = append(partials, templates.PartialLibrary{
+			Name: fmt.Sprintf("%s@%s", repository.GetName(), reference),
+			FS:   os.DirFS(filepath.Join(temporaryDir, filepath.FromSlash(library.Path))),
+		})
+	}
+
+	for index := len(bases) - 1; index >= 0; index-- {
+		partials = appendPartialsDirectory(partials, bases[index].Path, PartialsDirectory(bases[index].Metadata), fmt.Sprintf("base %d", index+1))
+	}
+	partials = appendPartialsDirectory(partials, src, PartialsDirectory(cloneyMetadata), "")
+
+	return partials, cleanup, nil
+}
+
+// appendPartialsDirectory appends a partials directory to the partial libraries, if it exists.
+// The library is named after the directory, prefixed by 'prefix' if it is not empty.
+func appendPartialsDirectory(partials []templates.PartialLibrary, src, directory, prefix string) []templates.PartialLibrary {
+	directoryPath := filepath.Join(src, filepath.FromSlash(directory))
+	if info, err := os.Stat(directoryPath); err != nil || !info.IsDir() {
+		return partials
+	}
+	return append(partials, templates.PartialLibrary{
+		Name: path.Join(prefix, directory),
+		FS:   os.DirFS(directoryPath),
+	})
+}
+
+// RenderOptions converts the configuration, the partial libraries and the base templates of a template repository
+// into the options used to render its files.
+// The configuration was already validated when parsing the metadata file.
+func RenderOptions(configuration metadata.CloneyMetadataConfiguration, partials []templates.PartialLibrary, bases []Base) templates.RenderOptions {
+	options := templates.RenderOptions{
+		CopyOnlyPaths:      configuration.CopyOnly,
+		RenderOnlyPaths:    configuration.RenderOnly,
+		DelimiterOverrides: make(map[string]templates.Delimiters),
+		Partials:           partials,
+	}
+	if len(configuration.Delimiters) == 2 {
+		options.Delimiters = templates.Delimiters{Left: configuration.Delimiters[0], Right: configuration.Delimiters[1]}
+	}
+	for pattern, delimiters := range configuration.DelimiterOverrides {
+		options.DelimiterOverrides[pattern] = templates.Delimiters{Left: delimiters[0], Right: delimiters[1]}
+	}
+	for _, base := range bases {
+		var baseFS fs.FS = templates.NewDiskFS(base.Path)
+		if len(base.IgnorePaths) > 0 {
+			baseFS = templates.NewFilteredFS(baseFS, base.IgnorePaths)
+		}
+		options.Bases = append(options.Bases, baseFS)
+	}
+	return options
+}
@@ -30,6 +30,14 @@ type AppConfig struct {
 	// These paths typically include directories like .git and node_modules, which are not relevant to the template.
 	KnownIgnorePaths []string
 
+	// IgnoreFileName is the name of the optional file, in the template repository root, that lists paths to ignore
+	// with the '.gitignore' syntax.
+	IgnoreFileName string
+
+	// DefaultPartialsDirectoryName is the default directory, relative to the template repository root, holding partial templates.
+	// Its files are never generated.
+	DefaultPartialsDirectoryName string
+
 	// IgnorePrefix is the prefix used to ignore files and directories in the template repository.
 	IgnorePrefix string
 
@@ -47,10 +55,34 @@ type AppConfig struct {
 
 	// CloneyDocumentationURL is the URL of the Cloney documentation.
 	CloneyDocumentationURL string
+
+	// ProjectConfigFileName is the name of the optional configuration file in the current directory.
+	// Its settings take precedence over the settings of the user configuration file.
+	ProjectConfigFileName string
+
+	// The following settings can be changed in the configuration files.
+
+	// DefaultBranch is the git branch used when neither a branch nor a tag is given.
+	DefaultBranch string
+
+	// TokenEnvironmentVariables are the names of the environment variables holding the git token, checked in order
+	// after 'CLONEY_GIT_TOKEN' when no token is given.
+	TokenEnvironmentVariables []string
+
+	// CacheDirectory is the directory holding the repositories fetched while a command runs, such as base templates
+	// and partial libraries, until they are no longer needed. If empty, the default directory for temporary files is used.
+	CacheDirectory string
+
+	// DefaultVariables are template variables used when no other source defines them.
+	// They take precedence over the default values of the metadata file.
+	DefaultVariables map[string]interface{}
+
+	// Aliases are short names for template repositories, such as 'svc' for 'https://github.com/username/service.git'.
+	Aliases map[string]string
 }
 
-// globalConfig is the global application configuration.
-var globalConfig = &AppConfig{
+// defaultConfig is the application configuration before the configuration files are loaded.
+var defaultConfig = AppConfig{
 	//! AppVersion is set automatically during the pipeline that tags the release (.github/workflows/auto_tag.yaml).
 	//! Keep this value as it is.
 	AppVersion: "X.X.X",
@@ -66,13 +98,18 @@ var globalConfig = &AppConfig{
 	DefaultCloneyProjectName:     "cloney-template",
 
 	KnownIgnorePaths: []string{
-		".cloney.yaml",      // Cloney metadata file.
-		".cloney-vars.yaml", // Cloney default user variables file.
-		".git",              // Git directory.
-		"node_modules",      // Node.js modules directory.
-		".venv",             // Python virtual environment directory.
+		".cloney.yaml",        // Cloney metadata file.
+		".cloney-vars.yaml",   // Cloney default user variables file.
+		".cloneyignore",       // Cloney ignore file.
+		".cloney-config.yaml", // Cloney project configuration file.
+		".git",                // Git directory.
+		"node_modules",        // Node.js modules directory.
+		".venv",               // Python virtual environment directory.
 	},
-	IgnorePrefix: "__",
+	IgnoreFileName: ".cloneyignore",
+	IgnorePrefix:   "__",
+
+	DefaultPartialsDirectoryName: ".cloney/partials",
 
 	DefaultMetadataDescriptionValue:     "A Cloney template repository",
 	DefaultMetadataLicenseValue:         "MIT",
@@ -80,9 +117,24 @@ var globalConfig = &AppConfig{
 
 	CloneyExampleRepositoryURL: "https://github.com/ArthurSudbrackIbarra/cloney-example.git",
 	CloneyDocumentationURL:     "https://arthursudbrackibarra.github.io/cloney-documentation",
+
+	ProjectConfigFileName: ".cloney-config.yaml",
+	DefaultBranch:         "main",
 }
 
+// globalConfig is the global application configuration.
+var globalConfig = func() *AppConfig {
+	config := defaultConfig
+	return &config
+}()
+
 // GetAppConfig returns a copy of the global application configuration.
 func GetAppConfig() AppConfig {
 	return *globalConfig
 }
+
+// DefaultAppConfig returns a copy of the application configuration before the configuration files are loaded.
+func DefaultAppConfig() AppConfig {
+	config := defaultConfig
+	return config
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the content of a configuration file.
+// The 'token_env' and 'cache_dir' settings can only be set in the user configuration file, since the project
+// configuration file may come from a repository that is not trusted to choose the git token or where files are written.
+//
+//	default_branch: develop
+//	token_env: [GITLAB_TOKEN]
+//	cache_dir: ~/.cache/cloney
+//	variables:
+//	  author: Jane Doe
+//	aliases:
+//	  svc: https://github.com/username/service.git
+type configFile struct {
+	// DefaultBranch is the git branch used when neither a branch nor a tag is given.
+	DefaultBranch string `yaml:"default_branch"`
+
+	// TokenEnv are the names of the environment variables holding the git token.
+	TokenEnv []string `yaml:"token_env"`
+
+	// CacheDir is the directory holding the fetched repositories. Relative paths are relative to the configuration file.
+	CacheDir string `yaml:"cache_dir"`
+
+	// Variables are template variables used when no other source defines them.
+	Variables map[string]interface{} `yaml:"variables"`
+
+	// Aliases are short names for template repositories. Local paths starting with './' or '../' are relative to
+	// the configuration file.
+	Aliases map[string]string `yaml:"aliases"`
+}
+
+// UserConfigFilePath returns the path of the user configuration file: the 'CLONEY_CONFIG' environment variable if it is
+// set, or 'cloney/config.yaml' in the '$XDG_CONFIG_HOME' directory, which defaults to '~/.config'.
+func UserConfigFilePath() (string, error) {
+	if path := os.Getenv("CLONEY_CONFIG"); path != "" {
+		return path, nil
+	}
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configDir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configDir, "cloney", "config.yaml"), nil
+}
+
+// LoadConfigFiles loads the user configuration file and the project configuration file of the working directory,
+// if they exist, into the global application configuration. The settings of the project configuration file take
+// precedence over the settings of the user configuration file, and variables and aliases are merged by name.
+// Command-line flags and environment variables take precedence over both. Loading again starts from the defaults.
+func LoadConfigFiles(workingDir string) error {
+	loaded := defaultConfig
+	loaded.DefaultVariables = make(map[string]interface{})
+	loaded.Aliases = make(map[string]string)
+
+	// The user configuration file is optional, unless its path is given by the 'CLONEY_CONFIG' environment variable.
+	userConfigFilePath, err := UserConfigFilePath()
+	if err == nil {
+		err = loadConfigFile(&loaded, userConfigFilePath, os.Getenv("CLONEY_CONFIG") != "", false)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = loadConfigFile(&loaded, filepath.Join(workingDir, defaultConfig.ProjectConfigFileName), false, true)
+	if err != nil {
+		return err
+	}
+
+	*globalConfig = loaded
+	return nil
+}
+
+// loadConfigFile reads a configuration file and applies its settings to the configuration.
+// Missing files are ignored, unless 'required' is true. Project configuration files cannot set 'token_env' and 'cache_dir'.
+func loadConfigFile(config *AppConfig, path string, required, project bool) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read the configuration file '%s': %w", path, err)
+	}
+
+	// Unknown settings are refused, so typos are not silently ignored.
+	var file configFile
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid configuration file '%s': %w", path, err)
+	}
+	if project && (file.TokenEnv != nil || file.CacheDir != "") {
+		return fmt.Errorf("invalid configuration file '%s': 'token_env' and 'cache_dir' can only be set in the user configuration file", path)
+	}
+
+	if file.DefaultBranch != "" {
+		config.DefaultBranch = file.DefaultBranch
+	}
+	if file.TokenEnv != nil {
+		config.TokenEnvironmentVariables = file.TokenEnv
+	}
+	if file.CacheDir != "" {
+		config.CacheDirectory = expandPath(file.CacheDir, filepath.Dir(path))
+	}
+	for name, value := range file.Variables {
+		config.DefaultVariables[name] = value
+	}
+	for name, source := range file.Aliases {
+		if name == "" || source == "" {
+			return fmt.Errorf("invalid configuration file '%s': aliases must have a name and a source", path)
+		}
+		// Local paths are relative to the configuration file, like the cache directory.
+		if source == "." || source == "~" || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") || strings.HasPrefix(source, "~/") {
+			source = expandPath(source, filepath.Dir(path))
+		}
+		config.Aliases[name] = source
+	}
+	return nil
+}
+
+// expandPath replaces a leading '~' by the home directory, and makes relative paths relative to 'relativeTo'.
+func expandPath(path, relativeTo string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(homeDir, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(relativeTo, path)
+	}
+	return path
+}
+
+// ResolveAlias returns the source of a template repository alias, or the given source if it is not an alias.
+func (c AppConfig) ResolveAlias(source string) string {
+	if aliasSource, ok := c.Aliases[source]; ok {
+		return aliasSource
+	}
+	return source
+}
+
+// CreateTemporaryDirectory creates a new directory for a fetched repository in the cache directory, which is created
+// if needed, or in the default directory for temporary files if no cache directory is configured.
+func CreateTemporaryDirectory(pattern string) (string, error) {
+	return CreateTemporaryDirectoryIn(GetAppConfig().CacheDirectory, pattern)
+}
+
+// CreateTemporaryDirectoryIn creates a new directory for a fetched repository in the given cache directory, which is
+// created if needed, or in the default directory for temporary files if it is empty.
+func CreateTemporaryDirectoryIn(directory, pattern string) (string, error) {
+	if directory != "" {
+		if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+			return "", fmt.Errorf("could not create the cache directory '%s': %w", directory, err)
+		}
+	}
+	return os.MkdirTemp(directory, pattern)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadConfigFiles tests loading a user and a project configuration file.
+// The settings of the project configuration file should take precedence, with variables and aliases merged by name.
+func TestLoadConfigFiles(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { *globalConfig = defaultConfig }()
+
+	userDir := t.TempDir()
+	projectDir := t.TempDir()
+	userConfigFilePath := filepath.Join(userDir, "config.yaml")
+	assert.Nil(os.WriteFile(userConfigFilePath, []byte(`
+default_branch: develop
+token_env: [GITLAB_TOKEN]
+cache_dir: cache
+variables:
+  author: Jane Doe
+  license: MIT
+aliases:
+  svc: https://github.com/username/service.git
+`), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(projectDir, defaultConfig.ProjectConfigFileName), []byte(`
+default_branch: release
+variables:
+  license: Apache-2.0
+aliases:
+  db: https://github.com/username/database.git
+  local: ./templates/local
+`), 0644))
+	t.Setenv("CLONEY_CONFIG", userConfigFilePath)
+
+	assert.Nil(LoadConfigFiles(projectDir))
+	config := GetAppConfig()
+	assert.Equal("release", config.DefaultBranch)
+	assert.Equal([]string{"GITLAB_TOKEN"}, config.TokenEnvironmentVariables)
+	assert.Equal(filepath.Join(userDir, "cache"), config.CacheDirectory)
+	assert.Equal(map[string]interface{}{"author": "Jane Doe", "license": "Apache-2.0"}, config.DefaultVariables)
+	assert.Equal("https://github.com/username/service.git", config.ResolveAlias("svc"))
+	assert.Equal("https://github.com/username/database.git", config.ResolveAlias("db"))
+	assert.Equal(filepath.Join(projectDir, "templates", "local"), config.ResolveAlias("local"))
+	assert.Equal("./local", config.ResolveAlias("./local"))
+
+	// Loading again without the project configuration file starts from the defaults.
+	assert.Nil(LoadConfigFiles(t.TempDir()))
+	assert.Equal("develop", GetAppConfig().DefaultBranch)
+	assert.Equal("MIT", GetAppConfig().DefaultVariables["license"])
+}
+
+// TestLoadConfigFilesWhenInvalid tests loading configuration files with unknown settings, a missing file given by the
+// 'CLONEY_CONFIG' environment variable, or a project file setting the git token or the cache directory.
+// It should return an error and keep the previous configuration.
+func TestLoadConfigFilesWhenInvalid(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { *globalConfig = defaultConfig }()
+
+	userConfigFilePath := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(os.WriteFile(userConfigFilePath, []byte("default_brnach: develop\n"), 0644))
+	t.Setenv("CLONEY_CONFIG", userConfigFilePath)
+	assert.NotNil(LoadConfigFiles(t.TempDir()))
+	assert.Equal("main", GetAppConfig().DefaultBranch)
+
+	t.Setenv("CLONEY_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.NotNil(LoadConfigFiles(t.TempDir()))
+
+	// The git token and the cache directory can only be set in the user configuration file.
+	assert.Nil(os.WriteFile(userConfigFilePath, []byte("default_branch: develop\n"), 0644))
+	t.Setenv("CLONEY_CONFIG", userConfigFilePath)
+	for _, setting := range []string{"token_env: [ATTACKER_TOKEN]", "cache_dir: /tmp/cloney"} {
+		projectDir := t.TempDir()
+		assert.Nil(os.WriteFile(filepath.Join(projectDir, defaultConfig.ProjectConfigFileName), []byte(setting+"\n"), 0644))
+		err := LoadConfigFiles(projectDir)
+		if assert.Error(err, setting) {
+			assert.Contains(err.Error(), "can only be set in the user configuration file")
+		}
+		assert.Equal("main", GetAppConfig().DefaultBranch)
+	}
+}
@@ -1,11 +1,15 @@
 package git
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
 
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/config"
+
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
@@ -25,6 +29,9 @@ type GitRepository struct {
 
 	// Auth is the authentication method to use when cloning the repository.
 	Auth transport.AuthMethod
+
+	// Progress receives the progress messages of the git server while cloning, if it is not nil.
+	Progress io.Writer
 }
 
 // repositoryRegex is a regular expression to match a git repository URL.
@@ -72,7 +79,8 @@ func (r *GitRepository) AuthenticateWithToken(token string) {
 }
 
 // Clone clones the git repository.
-func (r *GitRepository) Clone(path string) error {
+// The clone is aborted if the context is cancelled, leaving the path partially written.
+func (r *GitRepository) Clone(ctx context.Context, path string) error {
 	var referenceName plumbing.ReferenceName
 	// If the branch is specified, use it as the reference name.
 	// If the tag is specified, use it as the reference name.
@@ -89,20 +97,26 @@ func (r *GitRepository) Clone(path string) error {
 	}
 
 	// Clone the repository.
-	_, err := git.PlainClone(path, false, &git.CloneOptions{
+	_, err := git.PlainCloneContext(ctx, path, false, &git.CloneOptions{
 		URL:           r.URL,
 		ReferenceName: referenceName,
 		Auth:          auth,
+		Progress:      r.Progress,
 	})
 	return err
 }
 
 // GetFileContent returns the content of a raw file in the git repository.
-func (r *GitRepository) GetFileContent(filePath string) (string, error) {
+func (r *GitRepository) GetFileContent(ctx context.Context, filePath string) (string, error) {
 	// Clone the repository in a temporary directory.
-	temporaryDir := fmt.Sprintf("%s/cloney/%s", os.TempDir(), r.GetName())
-	err := r.Clone(temporaryDir)
+	temporaryDir, err := config.CreateTemporaryDirectory(fmt.Sprintf("cloney-%s-*", r.GetName()))
+	if err != nil {
+		return "", err
+	}
+	err = r.Clone(ctx, temporaryDir)
 	if err != nil {
+		// Clean up the partially cloned repository on error.
+		os.RemoveAll(temporaryDir)
 		return "", err
 	}
 
@@ -0,0 +1,111 @@
+package metadata
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Constants for the strategies used when several composed templates generate a file with the same path.
+const (
+	ERROR_COLLISION_STRATEGY = "error"
+	ORDER_COLLISION_STRATEGY = "order"
+)
+
+// SupportedCollisionStrategies is the list of supported collision strategies.
+var SupportedCollisionStrategies = []string{
+	ERROR_COLLISION_STRATEGY,
+	ORDER_COLLISION_STRATEGY,
+}
+
+// CompositionTemplate represents one of the templates of a composition manifest.
+type CompositionTemplate struct {
+	// Source is the git repository URL or the local path of the template.
+	// Local paths are relative to the directory of the composition manifest.
+	Source string `yaml:"source"`
+
+	// Branch is the branch of the git repository. If neither a branch nor a tag is defined, 'main' is used.
+	Branch string `yaml:"branch"`
+
+	// Tag is the tag of the git repository.
+	Tag string `yaml:"tag"`
+}
+
+// CompositionManifest represents a file listing several templates to be generated into the same directory,
+// such as a base service template followed by add-on templates.
+type CompositionManifest struct {
+	// Templates is the list of templates, in order.
+	Templates []CompositionTemplate `yaml:"templates"`
+
+	// Collisions is the strategy used when several templates generate a file with the same path:
+	// 'error' refuses to generate the files, and 'order' keeps the file of the last template declared.
+	Collisions string `yaml:"collisions"`
+}
+
+// NewCompositionManifestFromFile reads and validates a composition manifest file.
+func NewCompositionManifestFromFile(filePath string) (*CompositionManifest, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the composition manifest '%s': %w", filePath, err)
+	}
+
+	var manifest CompositionManifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse the composition manifest '%s': %w", filePath, err)
+	}
+
+	if len(manifest.Templates) == 0 {
+		return nil, fmt.Errorf("the composition manifest '%s' must list at least one template in field 'templates'", filePath)
+	}
+	for index, template := range manifest.Templates {
+		if strings.TrimSpace(template.Source) == "" {
+			return nil, fmt.Errorf("missing required field 'source' for template %d in field 'templates'", index+1)
+		}
+		if template.Branch != "" && template.Tag != "" {
+			return nil, fmt.Errorf("template '%s' in field 'templates' cannot define a branch and a tag at the same time", template.Source)
+		}
+	}
+	if manifest.Collisions != "" {
+		if err := ValidateCollisionStrategy(manifest.Collisions); err != nil {
+			return nil, err
+		}
+	}
+
+	return &manifest, nil
+}
+
+// ValidateCollisionStrategy checks that a collision strategy is supported.
+func ValidateCollisionStrategy(strategy string) error {
+	for _, supportedStrategy := range SupportedCollisionStrategies {
+		if strategy == supportedStrategy {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported collision strategy '%s', expected one of: %s", strategy, strings.Join(SupportedCollisionStrategies, ", "))
+}
+
+// Compose returns the metadata of a template composed with the metadata of the templates declared before it.
+// Variables share a single namespace: a variable declared by several templates must have examples of the same type,
+// and the declaration of the template declared last is kept. The configuration is merged as in 'Extend'.
+func (m *CloneyMetadata) Compose(previous *CloneyMetadata) (*CloneyMetadata, error) {
+	previousVariables := make(map[string]CloneyMetadataVariable)
+	for _, variable := range previous.Variables {
+		previousVariables[variable.Name] = variable
+	}
+	for _, variable := range m.Variables {
+		previousVariable, ok := previousVariables[variable.Name]
+		if ok && !AreVariablesSameType(previousVariable.Example, variable.Example) {
+			return nil, fmt.Errorf(
+				"variable '%s' is declared as '%s' by template '%s' and as '%s' by template '%s'",
+				variable.Name,
+				VariableType(previousVariable.Example),
+				previous.Name,
+				VariableType(variable.Example),
+				m.Name,
+			)
+		}
+	}
+	return m.Extend(previous), nil
+}
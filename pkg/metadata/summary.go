@@ -0,0 +1,107 @@
+package metadata
+
+import (
+	"strings"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+)
+
+// CloneyMetadataSummary is the machine-readable representation of a template repository metadata,
+// printed by the 'info' command in the 'json' and 'yaml' output formats.
+type CloneyMetadataSummary struct {
+	// Name is the template repository name.
+	Name string `json:"name" yaml:"name"`
+
+	// Description is the template repository description.
+	Description string `json:"description" yaml:"description"`
+
+	// TemplateVersion is the version of the template repository.
+	TemplateVersion string `json:"template_version" yaml:"template_version"`
+
+	// ManifestVersion is the version of the manifest file.
+	ManifestVersion string `json:"manifest_version" yaml:"manifest_version"`
+
+	// Authors is the list of authors of the template repository.
+	Authors []string `json:"authors" yaml:"authors"`
+
+	// License is the license of the template repository.
+	License string `json:"license" yaml:"license"`
+
+	// Extends is the source of the base template extended by the template repository, if any.
+	Extends string `json:"extends,omitempty" yaml:"extends,omitempty"`
+
+	// Variables is the list of variables of the template repository.
+	Variables []CloneyMetadataVariableSummary `json:"variables" yaml:"variables"`
+}
+
+// CloneyMetadataVariableSummary is the machine-readable representation of a template repository variable.
+type CloneyMetadataVariableSummary struct {
+	// Name is the variable name.
+	Name string `json:"name" yaml:"name"`
+
+	// Description is the variable description.
+	Description string `json:"description" yaml:"description"`
+
+	// Type is the type of the variable, such as 'string' or 'list'. The types of the items of lists and maps
+	// are given by the example value.
+	Type string `json:"type" yaml:"type"`
+
+	// Required is true if the variable has no default value.
+	Required bool `json:"required" yaml:"required"`
+
+	// Secret is true if the variable holds a secret value. Its default and example values are redacted.
+	Secret bool `json:"secret" yaml:"secret"`
+
+	// Default is the default value of the variable, if any.
+	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Example is an example value of the variable.
+	Example interface{} `json:"example" yaml:"example"`
+}
+
+// Summary returns the machine-readable representation of the metadata.
+func (m *CloneyMetadata) Summary() CloneyMetadataSummary {
+	summary := CloneyMetadataSummary{
+		Name:            m.Name,
+		Description:     m.Description,
+		TemplateVersion: m.TemplateVersion,
+		ManifestVersion: m.ManifestVersion,
+		Authors:         m.Authors,
+		License:         m.License,
+		Variables:       []CloneyMetadataVariableSummary{},
+	}
+	if summary.Authors == nil {
+		summary.Authors = []string{}
+	}
+	if m.Extends != nil {
+		summary.Extends = m.Extends.Source
+	}
+
+	for _, variable := range m.Variables {
+		// The type of lists and maps is followed by the structure of their items, which the example value already shows.
+		variableType := VariableType(variable.Example)
+		if index := strings.IndexAny(variableType, " \n"); index >= 0 {
+			variableType = variableType[:index]
+		}
+		variableSummary := CloneyMetadataVariableSummary{
+			Name:        variable.Name,
+			Description: variable.Description,
+			Type:        variableType,
+			Required:    variable.Default == nil,
+			Secret:      variable.Secret,
+			Default:     variable.Default,
+			Example:     variable.Example,
+		}
+
+		// Values of secret variables are never printed.
+		if variable.Secret {
+			if variable.Default != nil {
+				variableSummary.Default = terminal.RedactedValue
+			}
+			variableSummary.Example = terminal.RedactedValue
+		}
+		summary.Variables = append(summary.Variables, variableSummary)
+	}
+
+	return summary
+}
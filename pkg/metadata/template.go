@@ -2,8 +2,14 @@ package metadata
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/git"
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
 
 	"github.com/go-playground/validator/v10"
@@ -14,6 +20,109 @@ import (
 type CloneyMetadataConfiguration struct {
 	// IgnorePaths is the list of paths to ignore when cloning the template repository.
 	IgnorePaths []string `yaml:"ignore_paths"`
+
+	// CopyOnly is the list of glob patterns of files that are copied as they are, without being rendered.
+	// Binary files are detected automatically, so this is only needed for text files that should not be rendered.
+	CopyOnly []string `yaml:"copy_only"`
+
+	// RenderOnly is the list of glob patterns of files that are always rendered, even if they are detected as binary.
+	RenderOnly []string `yaml:"render_only"`
+
+	// Modes maps glob patterns to octal file modes, such as '0755', overriding the modes of the matching files.
+	Modes map[string]string `yaml:"modes"`
+
+	// Delimiters are the left and right action delimiters of the template files, such as ["[[", "]]"].
+	// They are useful for files that use '{{' and '}}' natively, such as Helm charts and GitHub Actions workflows.
+	Delimiters []string `yaml:"delimiters"`
+
+	// DelimiterOverrides maps glob patterns to the action delimiters of the matching files, overriding 'Delimiters'.
+	DelimiterOverrides map[string][]string `yaml:"delimiter_overrides"`
+
+	// PartialsDir is the directory, relative to the template repository root, holding partial templates.
+	// Its 'define' blocks can be used with 'include' in every file, and its files are never generated.
+	// If empty, '.cloney/partials' is used.
+	PartialsDir string `yaml:"partials_dir"`
+
+	// Libraries is the list of remote libraries of partial templates.
+	// Their 'define' blocks can be used with 'include' in every file, and the partials directory can override them.
+	Libraries []CloneyMetadataLibrary `yaml:"libraries"`
+}
+
+// CloneyMetadataLibrary represents a remote library of partial templates stored in a git repository.
+type CloneyMetadataLibrary struct {
+	// URL is the URL of the git repository of the library.
+	URL string `yaml:"url"`
+
+	// Branch is the branch of the git repository. If neither a branch nor a tag is defined, 'main' is used.
+	Branch string `yaml:"branch"`
+
+	// Tag is the tag of the git repository.
+	Tag string `yaml:"tag"`
+
+	// Path is the directory of the git repository holding the partial templates. If empty, the repository root is used.
+	Path string `yaml:"path"`
+}
+
+// ValidatePartials checks that the partials directory stays inside the template repository,
+// and that each library has a valid git repository URL and at most one of a branch and a tag.
+func (c CloneyMetadataConfiguration) ValidatePartials() error {
+	if c.PartialsDir != "" && !isRelativeDirectory(c.PartialsDir) {
+		return fmt.Errorf("invalid field 'partials_dir': '%s' must be a directory inside the template repository", c.PartialsDir)
+	}
+	for index, library := range c.Libraries {
+		if !git.MatchesGitRepositoryURL(library.URL) {
+			return fmt.Errorf("invalid URL '%s' for library %d in field 'libraries'", library.URL, index+1)
+		}
+		if library.Branch != "" && library.Tag != "" {
+			return fmt.Errorf("library '%s' in field 'libraries' cannot define a branch and a tag at the same time", library.URL)
+		}
+		if library.Path != "" && !isRelativeDirectory(library.Path) {
+			return fmt.Errorf("invalid path '%s' for library '%s' in field 'libraries': it must be a directory inside the repository", library.Path, library.URL)
+		}
+	}
+	return nil
+}
+
+// isRelativeDirectory returns true if a path is relative and does not leave the directory it is relative to.
+func isRelativeDirectory(directory string) bool {
+	cleanPath := path.Clean(filepath.ToSlash(directory))
+	return fs.ValidPath(cleanPath) && cleanPath != "."
+}
+
+// ValidateDelimiters checks that the action delimiters of the configuration are pairs of non-empty strings.
+func (c CloneyMetadataConfiguration) ValidateDelimiters() error {
+	if c.Delimiters != nil {
+		if err := validateDelimiterPair(c.Delimiters); err != nil {
+			return fmt.Errorf("invalid field 'delimiters': %w", err)
+		}
+	}
+	for pattern, delimiters := range c.DelimiterOverrides {
+		if err := validateDelimiterPair(delimiters); err != nil {
+			return fmt.Errorf("invalid delimiters for pattern '%s' in field 'delimiter_overrides': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// validateDelimiterPair checks that a list of delimiters has a non-empty left and right delimiter.
+func validateDelimiterPair(delimiters []string) error {
+	if len(delimiters) != 2 || strings.TrimSpace(delimiters[0]) == "" || strings.TrimSpace(delimiters[1]) == "" {
+		return fmt.Errorf("expected a left and a right delimiter, such as [\"[[\", \"]]\"]")
+	}
+	return nil
+}
+
+// FileModes returns the file mode overrides of the configuration, parsed from their octal representation.
+func (c CloneyMetadataConfiguration) FileModes() (map[string]os.FileMode, error) {
+	fileModes := make(map[string]os.FileMode)
+	for pattern, rawMode := range c.Modes {
+		mode, err := strconv.ParseUint(rawMode, 8, 32)
+		if err != nil || mode > 0777 {
+			return nil, fmt.Errorf("invalid mode '%s' for pattern '%s' in field 'modes', expected an octal value such as '0755'", rawMode, pattern)
+		}
+		fileModes[pattern] = os.FileMode(mode)
+	}
+	return fileModes, nil
 }
 
 // CloneyMetadataVariable represents a variable in a Cloney template repository.
@@ -34,6 +143,42 @@ type CloneyMetadataVariable struct {
 	// It is a pointer to a bool because if the field is not defined in the YAML file,
 	// the default value should be true.
 	Validate *bool `yaml:"validate"`
+
+	// Secret specifies if the variable holds a secret value, such as a token or a password.
+	// Secret values are hidden when prompted, and redacted in the terminal output, in the 'json' and 'yaml' results
+	// of the commands and in error messages, except for booleans and numbers. They are rendered into the generated
+	// files like any other value, and left empty in the files generated by the 'vars' command.
+	Secret bool `yaml:"secret"`
+}
+
+// CloneyMetadataExtends represents the base template extended by a Cloney template repository.
+// The files and variables of the base template are inherited, and the template repository overrides them by path and by name.
+type CloneyMetadataExtends struct {
+	// Source is the git repository URL or the local path of the base template.
+	// Local paths are relative to the directory of the template repository.
+	Source string `yaml:"source"`
+
+	// Branch is the branch of the git repository. If neither a branch nor a tag is defined, 'main' is used.
+	Branch string `yaml:"branch"`
+
+	// Tag is the tag of the git repository.
+	Tag string `yaml:"tag"`
+}
+
+// IsRemote returns true if the base template is a git repository.
+func (e *CloneyMetadataExtends) IsRemote() bool {
+	return git.MatchesGitRepositoryURL(e.Source)
+}
+
+// Validate checks that the base template has a source and at most one of a branch and a tag.
+func (e *CloneyMetadataExtends) Validate() error {
+	if strings.TrimSpace(e.Source) == "" {
+		return fmt.Errorf("missing required field 'source' in field 'extends'")
+	}
+	if e.Branch != "" && e.Tag != "" {
+		return fmt.Errorf("field 'extends' cannot define a branch and a tag at the same time")
+	}
+	return nil
 }
 
 // CloneyMetadata represents the metadata file of a Cloney template repository.
@@ -56,6 +201,9 @@ type CloneyMetadata struct {
 	// License is the license of the template repository.
 	License string `yaml:"license"`
 
+	// Extends is the base template extended by the template repository, if any.
+	Extends *CloneyMetadataExtends `yaml:"extends"`
+
 	// Configuration is the configuration of the template repository.
 	Configuration CloneyMetadataConfiguration `yaml:"configuration"`
 
@@ -117,6 +265,28 @@ func NewCloneyMetadataFromRawYAML(rawYAML string, supportedManifestVersions []st
 		)
 	}
 
+	// Validate the file mode overrides.
+	if _, err := metadata.Configuration.FileModes(); err != nil {
+		return nil, err
+	}
+
+	// Validate the action delimiters.
+	if err := metadata.Configuration.ValidateDelimiters(); err != nil {
+		return nil, err
+	}
+
+	// Validate the partials directory and the partial libraries.
+	if err := metadata.Configuration.ValidatePartials(); err != nil {
+		return nil, err
+	}
+
+	// Validate the base template.
+	if metadata.Extends != nil {
+		if err := metadata.Extends.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate variables separately because 'validator' package does not validate struct slices.
 	for _, variable := range metadata.Variables {
 		err = validate.Struct(variable)
@@ -150,6 +320,64 @@ func NewCloneyMetadataFromRawYAML(rawYAML string, supportedManifestVersions []st
 	return &metadata, nil
 }
 
+// Extend returns the metadata of the template repository merged with the metadata of its base template.
+//
+// Variables are inherited from the base template, and a variable with the same name overrides the inherited one.
+// Ignore paths, copy-only and render-only patterns and libraries are appended to the inherited ones, mode and delimiter
+// overrides are merged by pattern, and the delimiters and the partials directory of the template repository are kept,
+// unless only the base template defines delimiters. Everything else is kept as it is in the template repository.
+func (m *CloneyMetadata) Extend(base *CloneyMetadata) *CloneyMetadata {
+	merged := *m
+
+	// Inherit the variables, replacing the overridden ones in place.
+	overrides := make(map[string]CloneyMetadataVariable)
+	for _, variable := range m.Variables {
+		overrides[variable.Name] = variable
+	}
+	merged.Variables = nil
+	for _, variable := range base.Variables {
+		if override, ok := overrides[variable.Name]; ok {
+			variable = override
+			delete(overrides, variable.Name)
+		}
+		merged.Variables = append(merged.Variables, variable)
+	}
+	for _, variable := range m.Variables {
+		if _, ok := overrides[variable.Name]; ok {
+			merged.Variables = append(merged.Variables, variable)
+		}
+	}
+
+	// Merge the configuration.
+	configuration := &merged.Configuration
+	configuration.IgnorePaths = append(append([]string(nil), base.Configuration.IgnorePaths...), m.Configuration.IgnorePaths...)
+	configuration.CopyOnly = append(append([]string(nil), base.Configuration.CopyOnly...), m.Configuration.CopyOnly...)
+	configuration.RenderOnly = append(append([]string(nil), base.Configuration.RenderOnly...), m.Configuration.RenderOnly...)
+	configuration.Libraries = append(append([]CloneyMetadataLibrary(nil), base.Configuration.Libraries...), m.Configuration.Libraries...)
+	configuration.Modes = mergeMaps(base.Configuration.Modes, m.Configuration.Modes)
+	configuration.DelimiterOverrides = mergeMaps(base.Configuration.DelimiterOverrides, m.Configuration.DelimiterOverrides)
+	if configuration.Delimiters == nil {
+		configuration.Delimiters = base.Configuration.Delimiters
+	}
+
+	return &merged
+}
+
+// mergeMaps returns a new map with the entries of 'base', overridden by the entries of 'overrides'.
+func mergeMaps[V any](base, overrides map[string]V) map[string]V {
+	if base == nil && overrides == nil {
+		return nil
+	}
+	merged := make(map[string]V, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
+
 // MatchUserVariables validates if a given map of variables matches the variables defined
 // in the template repository metadata file.
 // It also adds the default values of the variables to the user variables if they are not defined.
@@ -191,6 +419,76 @@ func (m *CloneyMetadata) MatchUserVariables(userVariables map[string]interface{}
 	return userVariables, nil
 }
 
+// SecretValues returns the string values of the secret variables in the given user variables.
+// Values of lists and maps are returned element by element, and values of other types, such as booleans, are skipped.
+func (m *CloneyMetadata) SecretValues(userVariables map[string]interface{}) []string {
+	var values []string
+	for _, variable := range m.Variables {
+		if variable.Secret {
+			values = append(values, stringValues(userVariables[variable.Name])...)
+		}
+	}
+	return values
+}
+
+// stringValues returns all string values within a value, recursively.
+func stringValues(value interface{}) []string {
+	switch typedValue := value.(type) {
+	case string:
+		return []string{typedValue}
+	case map[string]interface{}:
+		var values []string
+		for _, item := range typedValue {
+			values = append(values, stringValues(item)...)
+		}
+		return values
+	case []interface{}:
+		var values []string
+		for _, item := range typedValue {
+			values = append(values, stringValues(item)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// RedactSecrets returns a copy of the user variables where every value of the secret variables is replaced by "********".
+// Lists and maps keep their structure, and only their values are replaced, so that the copy can be printed or rendered
+// in any format without revealing the secrets.
+func (m *CloneyMetadata) RedactSecrets(userVariables map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(userVariables))
+	for name, value := range userVariables {
+		redacted[name] = value
+	}
+	for _, variable := range m.Variables {
+		if value, contains := redacted[variable.Name]; contains && variable.Secret {
+			redacted[variable.Name] = redactValue(value)
+		}
+	}
+	return redacted
+}
+
+// redactValue returns a copy of a value where every scalar value is replaced by "********", recursively.
+func redactValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(typedValue))
+		for key, item := range typedValue {
+			redacted[key] = redactValue(item)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(typedValue))
+		for index, item := range typedValue {
+			redacted[index] = redactValue(item)
+		}
+		return redacted
+	}
+	return terminal.RedactedValue
+}
+
 // GetGeneralInfo returns the general information of the Cloney template repository as a string.
 func (m *CloneyMetadata) GetGeneralInfo() string {
 	result := terminal.WhiteBoldUnderline("\nGeneral Information\n\n")
@@ -199,6 +497,9 @@ func (m *CloneyMetadata) GetGeneralInfo() string {
 	result += fmt.Sprintf("%s: %s\n", "Template Version", m.TemplateVersion)
 	result += fmt.Sprintf("%s: %s\n", "Template License", m.License)
 	result += fmt.Sprintf("%s: %s\n", "Template Author(s)", strings.Join(m.Authors, ", "))
+	if m.Extends != nil {
+		result += fmt.Sprintf("%s: %s\n", "Template Base", m.Extends.Source)
+	}
 	return result
 }
 
@@ -206,10 +507,14 @@ func (m *CloneyMetadata) GetGeneralInfo() string {
 func (m *CloneyMetadata) GetVariables() string {
 	result := "\n"
 	for index, variable := range m.Variables {
+		var secretLabel string
+		if variable.Secret {
+			secretLabel = fmt.Sprintf(" (%s)", terminal.Red("Secret"))
+		}
 		if variable.Default == nil {
-			result += fmt.Sprintf("%s %s\n\n", terminal.WhiteBoldUnderline("Variable"), fmt.Sprintf("%s (%s)", terminal.BlueBoldUnderline(variable.Name), terminal.Yellow("Required")))
+			result += fmt.Sprintf("%s %s%s\n\n", terminal.WhiteBoldUnderline("Variable"), fmt.Sprintf("%s (%s)", terminal.BlueBoldUnderline(variable.Name), terminal.Yellow("Required")), secretLabel)
 		} else {
-			result += fmt.Sprintf("%s %s (Optional)\n\n", terminal.WhiteBoldUnderline("Variable"), terminal.BlueBoldUnderline(variable.Name))
+			result += fmt.Sprintf("%s %s (Optional)%s\n\n", terminal.WhiteBoldUnderline("Variable"), terminal.BlueBoldUnderline(variable.Name), secretLabel)
 		}
 
 		result += fmt.Sprintf("%s: %s\n", "Variable Description", variable.Description)
@@ -221,6 +526,18 @@ func (m *CloneyMetadata) GetVariables() string {
 			result += fmt.Sprintf("%s:\n%s\n", "Variable Type", VariableType(variable.Example))
 		}
 
+		// Values of secret variables are never printed.
+		if variable.Secret {
+			if variable.Default != nil {
+				result += fmt.Sprintf("%s: %s\n", "Default Value", terminal.RedactedValue)
+			}
+			result += fmt.Sprintf("%s: %s\n", "Example Value", terminal.RedactedValue)
+			if index != len(m.Variables)-1 {
+				result += "\n"
+			}
+			continue
+		}
+
 		if variable.Default != nil {
 			varDefault := VariableValue(variable.Default)
 			if !strings.Contains(varDefault, "\n") {
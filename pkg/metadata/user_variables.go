@@ -1,36 +1,111 @@
 package metadata
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
+// UserVariablesFileNotFoundError is returned when a variables file does not exist.
+type UserVariablesFileNotFoundError struct {
+	// FilePath is the path of the variables file.
+	FilePath string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the error message.
+func (e *UserVariablesFileNotFoundError) Error() string {
+	return fmt.Sprintf("the variables file '%s' does not exist", e.FilePath)
+}
+
+// Unwrap returns the underlying error.
+func (e *UserVariablesFileNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// UserVariablesEnvPrefix is the prefix of the environment variables that define user variables.
+// For example, the environment variable 'CLONEY_VAR_app_name' defines the 'app_name' variable.
+const UserVariablesEnvPrefix = "CLONEY_VAR_"
+
 // NewCloneyUserVariablesFromFile reads a file and returns a map of variables defined in it.
-// Supported file extensions: '.yaml' or '.yml'.
+// Supported file extensions: '.yaml', '.yml', '.json', '.toml' and '.env'.
+// Files named '.env' or starting with '.env.' are also parsed as '.env' files.
 func NewCloneyUserVariablesFromFile(filePath string) (map[string]interface{}, error) {
 	// Read file content.
+	// A missing file is reported differently from other errors, so users can spot typos in the file name.
 	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read the variables file: %w", err)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return nil, &UserVariablesFileNotFoundError{FilePath: filePath, Err: err}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the variables file '%s': %w", filePath, err)
 	}
 
 	variables := make(map[string]interface{})
 
-	// If the file extension is '.yaml' or '.yml', parse it as YAML.
-	// Otherwise, return an error.
-	if strings.HasSuffix(filePath, ".yaml") || strings.HasSuffix(filePath, ".yml") {
+	// Parse the file according to its extension.
+	fileName := filepath.Base(filePath)
+	switch {
+	case strings.HasSuffix(fileName, ".yaml") || strings.HasSuffix(fileName, ".yml"):
 		err = yaml.Unmarshal(content, &variables)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse the variables YAML file: %w", err)
+			return nil, fmt.Errorf("failed to parse the variables YAML file '%s': %w", filePath, err)
+		}
+	case strings.HasSuffix(fileName, ".json"):
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the variables JSON file '%s': %w", filePath, jsonErrorWithLine(content, err))
+		}
+	case strings.HasSuffix(fileName, ".toml"):
+		err = toml.Unmarshal(content, &variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the variables TOML file '%s': %w", filePath, err)
+		}
+	case strings.HasSuffix(fileName, ".env") || strings.HasPrefix(fileName, ".env."):
+		variables, err = parseEnvFile(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the variables .env file '%s': %w", filePath, err)
 		}
-	} else {
-		return nil, fmt.Errorf("unsupported file extension, expected '.yaml' or '.yml'")
+	default:
+		return nil, fmt.Errorf("unsupported extension for variables file '%s', expected '.yaml', '.yml', '.json', '.toml' or '.env'", filePath)
 	}
 
-	return variables, nil
+	return normalizeVariables(variables), nil
+}
+
+// IsInlineUserVariables returns true if a variables source is inline YAML rather than a file path.
+// A source is a file path if a file exists at that path. Otherwise, it is inline YAML if it is
+// a flow mapping (starts with '{'), spans multiple lines, or contains a 'key: value' pair.
+func IsInlineUserVariables(source string) bool {
+	if info, err := os.Stat(source); err == nil && !info.IsDir() {
+		return false
+	}
+	trimmedSource := strings.TrimSpace(source)
+	return strings.HasPrefix(trimmedSource, "{") ||
+		strings.Contains(trimmedSource, "\n") ||
+		strings.Contains(trimmedSource, ": ")
+}
+
+// NewCloneyUserVariablesFromSource returns a map of variables defined in a variables source,
+// which can either be a file path or inline YAML. See 'IsInlineUserVariables'.
+func NewCloneyUserVariablesFromSource(source string) (map[string]interface{}, error) {
+	if IsInlineUserVariables(source) {
+		return NewCloneyUserVariablesFromRawYAML(source)
+	}
+	return NewCloneyUserVariablesFromFile(source)
 }
 
 // NewCloneyUserVariablesFromRawYAML returns a map of variables defined in the given raw YAML string.
@@ -44,3 +119,126 @@ func NewCloneyUserVariablesFromRawYAML(rawYAML string) (map[string]interface{},
 
 	return variables, nil
 }
+
+// NewCloneyUserVariablesFromEnv returns a map of variables defined in environment variables
+// prefixed with 'CLONEY_VAR_'. 'environ' is a list of 'key=value' strings, as returned by 'os.Environ'.
+// Values are parsed as YAML scalars, so that types are preserved (e.g. 'true' is a boolean and '8080' is an integer).
+func NewCloneyUserVariablesFromEnv(environ []string) map[string]interface{} {
+	variables := make(map[string]interface{})
+
+	for _, entry := range environ {
+		key, value, found := strings.Cut(entry, "=")
+		if !found || !strings.HasPrefix(key, UserVariablesEnvPrefix) || key == UserVariablesEnvPrefix {
+			continue
+		}
+		variables[strings.TrimPrefix(key, UserVariablesEnvPrefix)] = ParseYAMLScalar(value)
+	}
+
+	return variables
+}
+
+// ParseYAMLScalar parses a string as a YAML value, so that types are preserved.
+// If the string is not valid YAML or is empty, the string itself is returned.
+func ParseYAMLScalar(value string) interface{} {
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil || parsed == nil {
+		return value
+	}
+	return parsed
+}
+
+// parseEnvFile parses the content of a '.env' file.
+// Each line has the format 'KEY=VALUE', optionally prefixed with 'export'. Empty lines and lines starting with '#' are ignored.
+// Single-quoted values are read literally, double-quoted values have their escape sequences interpreted,
+// and unquoted values end at the first ' #'. Values are then parsed as YAML scalars.
+func parseEnvFile(content []byte) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("line %d: expected 'KEY=VALUE'", lineNumber)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\""):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid double-quoted value: %w", lineNumber, err)
+			}
+			value = unquoted
+		default:
+			if index := strings.Index(value, " #"); index != -1 {
+				value = strings.TrimSpace(value[:index])
+			}
+		}
+
+		variables[key] = ParseYAMLScalar(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return variables, nil
+}
+
+// jsonErrorWithLine adds the line number to JSON syntax errors, which only report the byte offset.
+func jsonErrorWithLine(content []byte, err error) error {
+	var syntaxError *json.SyntaxError
+	if errors.As(err, &syntaxError) && syntaxError.Offset <= int64(len(content)) {
+		line := 1 + bytes.Count(content[:syntaxError.Offset], []byte("\n"))
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	return err
+}
+
+// normalizeVariables converts the values decoded from JSON and TOML files to the same types produced by the YAML parser,
+// so that they can be compared against the example values of the template repository metadata file.
+func normalizeVariables(variables map[string]interface{}) map[string]interface{} {
+	for key, value := range variables {
+		variables[key] = normalizeVariableValue(value)
+	}
+	return variables
+}
+
+// normalizeVariableValue converts a single decoded value, recursively. See 'normalizeVariables'.
+func normalizeVariableValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case json.Number:
+		if intValue, err := strconv.Atoi(typedValue.String()); err == nil {
+			return intValue
+		}
+		floatValue, _ := typedValue.Float64()
+		return floatValue
+	case int64:
+		return int(typedValue)
+	case map[string]interface{}:
+		return normalizeVariables(typedValue)
+	case []map[string]interface{}:
+		list := make([]interface{}, len(typedValue))
+		for index, item := range typedValue {
+			list[index] = normalizeVariables(item)
+		}
+		return list
+	case []interface{}:
+		for index, item := range typedValue {
+			typedValue[index] = normalizeVariableValue(item)
+		}
+		return typedValue
+	}
+	return value
+}
@@ -0,0 +1,156 @@
+package metadata
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MergeUserVariables deep merges the 'src' variables into the 'dst' variables and returns 'dst'.
+// Maps are merged recursively, while any other value in 'src' replaces the value in 'dst'.
+func MergeUserVariables(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{})
+	}
+	for key, srcValue := range src {
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[key] = MergeUserVariables(dstMap, srcMap)
+		} else {
+			dst[key] = srcValue
+		}
+	}
+	return dst
+}
+
+// pathSegmentRegex is a regular expression to match a segment of a variable path, such as 'services[0][1]'.
+var pathSegmentRegex = regexp.MustCompile(`^([^\[\]]+)((?:\[\d+\])*)$`)
+
+// pathIndexRegex is a regular expression to match the indexes of a variable path segment.
+var pathIndexRegex = regexp.MustCompile(`\[(\d+)\]`)
+
+// maxPathIndex is the largest list index accepted in a variable path, so that a typo does not allocate a huge list.
+const maxPathIndex = 65536
+
+// pathKey represents a step of a variable path: either a map key or a list index.
+type pathKey struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// parseVariablePath parses a variable path with dots and indexes, such as 'db.port' or 'services[0].name'.
+func parseVariablePath(path string) ([]pathKey, error) {
+	var keys []pathKey
+	for _, segment := range strings.Split(path, ".") {
+		matches := pathSegmentRegex.FindStringSubmatch(segment)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid variable path '%s'", path)
+		}
+		keys = append(keys, pathKey{name: matches[1]})
+		for _, indexMatch := range pathIndexRegex.FindAllStringSubmatch(matches[2], -1) {
+			index, err := strconv.Atoi(indexMatch[1])
+			if err != nil || index > maxPathIndex {
+				return nil, fmt.Errorf("invalid variable path '%s': list indexes cannot be greater than %d", path, maxPathIndex)
+			}
+			keys = append(keys, pathKey{index: index, isIndex: true})
+		}
+	}
+	return keys, nil
+}
+
+// SetUserVariable sets a value in the variables map following a path with dots and indexes,
+// such as 'db.port' or 'services[0].name'. Intermediate maps and lists are created as needed,
+// and lists are extended with empty values when the index is out of range.
+func SetUserVariable(variables map[string]interface{}, path string, value interface{}) error {
+	keys, err := parseVariablePath(path)
+	if err != nil {
+		return err
+	}
+	variables[keys[0].name] = setPathValue(variables[keys[0].name], keys[1:], value)
+	return nil
+}
+
+// setPathValue returns 'current' with 'value' set at the given path, creating containers as needed.
+func setPathValue(current interface{}, keys []pathKey, value interface{}) interface{} {
+	if len(keys) == 0 {
+		return value
+	}
+
+	key := keys[0]
+	if key.isIndex {
+		list, _ := current.([]interface{})
+		for len(list) <= key.index {
+			list = append(list, nil)
+		}
+		list[key.index] = setPathValue(list[key.index], keys[1:], value)
+		return list
+	}
+
+	currentMap, ok := current.(map[string]interface{})
+	if !ok {
+		currentMap = make(map[string]interface{})
+	}
+	currentMap[key.name] = setPathValue(currentMap[key.name], keys[1:], value)
+	return currentMap
+}
+
+// splitSetExpression splits a '--set' expression into its assignments.
+// Assignments are separated by commas, and commas can be escaped with a backslash.
+func splitSetExpression(expression string) []string {
+	var assignments []string
+	var current strings.Builder
+	for index := 0; index < len(expression); index++ {
+		char := expression[index]
+		if char == '\\' && index+1 < len(expression) && expression[index+1] == ',' {
+			current.WriteByte(',')
+			index++
+		} else if char == ',' {
+			assignments = append(assignments, current.String())
+			current.Reset()
+		} else {
+			current.WriteByte(char)
+		}
+	}
+	return append(assignments, current.String())
+}
+
+// ApplySetExpression applies a '--set' expression, such as 'db.port=5432,services[0].name=api', to the variables map.
+// If 'asString' is true, the values are kept as strings. Otherwise, they are parsed as YAML scalars.
+func ApplySetExpression(variables map[string]interface{}, expression string, asString bool) error {
+	for _, assignment := range splitSetExpression(expression) {
+		path, rawValue, found := strings.Cut(assignment, "=")
+		if !found || path == "" {
+			return fmt.Errorf("invalid assignment '%s', expected 'key=value'", assignment)
+		}
+
+		var value interface{} = rawValue
+		if !asString {
+			value = ParseYAMLScalar(rawValue)
+		}
+
+		if err := SetUserVariable(variables, path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplySetFileExpression applies a '--set-file' expression, such as 'token=./token.txt', to the variables map.
+// The value is the content of the file, without its trailing newline. This is useful for secrets and multi-line values.
+func ApplySetFileExpression(variables map[string]interface{}, expression string) error {
+	path, filePath, found := strings.Cut(expression, "=")
+	if !found || path == "" || filePath == "" {
+		return fmt.Errorf("invalid assignment '%s', expected 'key=path'", expression)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read the file for variable '%s': %w", path, err)
+	}
+
+	return SetUserVariable(variables, path, strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r"))
+}
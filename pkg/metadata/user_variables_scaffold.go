@@ -0,0 +1,195 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Constants for user variables file formats.
+const (
+	YAML_VARIABLES_FORMAT = "yaml"
+	JSON_VARIABLES_FORMAT = "json"
+	ENV_VARIABLES_FORMAT  = "env"
+)
+
+// SupportedVariablesFormats is the list of formats a user variables file can be generated in.
+var SupportedVariablesFormats = []string{
+	YAML_VARIABLES_FORMAT,
+	JSON_VARIABLES_FORMAT,
+	ENV_VARIABLES_FORMAT,
+}
+
+// scaffoldValue returns the value used for a variable in a generated user variables file.
+// The default value is used if defined, otherwise the example value is used.
+// Secret variables are left empty, so their values are never written to the file.
+func scaffoldValue(variable CloneyMetadataVariable) interface{} {
+	if variable.Secret {
+		return ""
+	}
+	if variable.Default != nil {
+		return variable.Default
+	}
+	return variable.Example
+}
+
+// scaffoldComments returns the comment lines describing a variable in a generated user variables file.
+func scaffoldComments(variable CloneyMetadataVariable) []string {
+	var comments []string
+	if variable.Description != "" {
+		comments = append(comments, variable.Description)
+	}
+
+	switch {
+	case variable.Secret && variable.Default != nil:
+		comments = append(comments, "Optional secret: left empty on purpose, the default value is used if it is removed.")
+	case variable.Secret:
+		comments = append(comments, "Required secret: left empty on purpose.")
+	case variable.Default != nil:
+		comments = append(comments, "Optional: the value below is the default value.")
+	default:
+		comments = append(comments, "Required: the value below is an example, replace it with your own.")
+	}
+	if variable.Secret {
+		comments = append(comments, fmt.Sprintf(
+			"Prefer the '%s%s' environment variable or the '--set-file' flag over storing it here.", UserVariablesEnvPrefix, variable.Name,
+		))
+	}
+
+	varType := VariableType(variable.Example)
+	if !strings.Contains(varType, "\n") {
+		comments = append(comments, fmt.Sprintf("Type: %s", varType))
+	} else {
+		comments = append(comments, "Type:")
+		for _, line := range strings.Split(strings.TrimRight(varType, "\n"), "\n") {
+			comments = append(comments, fmt.Sprintf("  %s", line))
+		}
+	}
+
+	if variable.Validate != nil && !*variable.Validate {
+		comments = append(comments, "The type of this variable is not validated.")
+	}
+
+	return comments
+}
+
+// ScaffoldUserVariables generates the content of a ready-to-edit user variables file
+// from the variables defined in the template repository metadata file.
+// Supported formats: 'yaml', 'json' and 'env'.
+func (m *CloneyMetadata) ScaffoldUserVariables(format string) (string, error) {
+	switch format {
+	case YAML_VARIABLES_FORMAT:
+		return m.scaffoldYAML()
+	case JSON_VARIABLES_FORMAT:
+		return m.scaffoldJSON()
+	case ENV_VARIABLES_FORMAT:
+		return m.scaffoldEnv()
+	}
+	return "", fmt.Errorf(
+		"unsupported variables format '%s', expected one of: %s", format, strings.Join(SupportedVariablesFormats, ", "),
+	)
+}
+
+// scaffoldYAML generates a user variables file in YAML format, with descriptions as comments.
+func (m *CloneyMetadata) scaffoldYAML() (string, error) {
+	result := fmt.Sprintf("# Variables for the '%s' template (version %s).\n", m.Name, m.TemplateVersion)
+	for _, variable := range m.Variables {
+		result += "\n"
+		for _, comment := range scaffoldComments(variable) {
+			result += fmt.Sprintf("# %s\n", comment)
+		}
+
+		// Encode a single-entry map so the value is correctly indented under its name.
+		var variableYAML bytes.Buffer
+		encoder := yaml.NewEncoder(&variableYAML)
+		encoder.SetIndent(2)
+		err := encoder.Encode(map[string]interface{}{
+			variable.Name: scaffoldValue(variable),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to generate YAML for variable '%s': %w", variable.Name, err)
+		}
+		result += variableYAML.String()
+	}
+	return result, nil
+}
+
+// scaffoldJSON generates a user variables file in JSON format.
+// JSON does not support comments, so only the values are generated, in the metadata file order.
+func (m *CloneyMetadata) scaffoldJSON() (string, error) {
+	if len(m.Variables) == 0 {
+		return "{}\n", nil
+	}
+
+	result := "{\n"
+	for index, variable := range m.Variables {
+		valueJSON, err := json.MarshalIndent(scaffoldValue(variable), "  ", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to generate JSON for variable '%s': %w", variable.Name, err)
+		}
+		nameJSON, _ := json.Marshal(variable.Name)
+		result += fmt.Sprintf("  %s: %s", nameJSON, valueJSON)
+		if index != len(m.Variables)-1 {
+			result += ","
+		}
+		result += "\n"
+	}
+	result += "}\n"
+	return result, nil
+}
+
+// yamlScalarText returns the text of a value as a single-line YAML scalar that is parsed back to the same value.
+// Lists and maps are written as inline JSON, which is also valid YAML.
+func yamlScalarText(value interface{}) (string, error) {
+	switch VariableType(value) {
+	case INTEGER_VARIABLE_TYPE, DECIMAL_VARIABLE_TYPE, BOOLEAN_VARIABLE_TYPE:
+		return VariableValue(value), nil
+	case STRING_VARIABLE_TYPE:
+		// Strings are written plainly, unless YAML would parse them as something else (e.g. 'true').
+		var parsed interface{}
+		if err := yaml.Unmarshal([]byte(value.(string)), &parsed); err == nil && parsed == value {
+			return value.(string), nil
+		}
+	}
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(valueJSON), nil
+}
+
+// envQuote quotes a value for a '.env' file if it would not survive a round trip unquoted.
+func envQuote(value string) string {
+	if !strings.ContainsAny(value, " #\"'\n\t") {
+		return value
+	}
+	// Single-quoted values are read literally.
+	if !strings.ContainsAny(value, "'\n") {
+		return fmt.Sprintf("'%s'", value)
+	}
+	// Double-quoted values have their escape sequences interpreted.
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+// scaffoldEnv generates a user variables file in '.env' format, with descriptions as comments.
+// Each value is written as a YAML scalar, so its type is preserved when it is read back.
+func (m *CloneyMetadata) scaffoldEnv() (string, error) {
+	result := fmt.Sprintf("# Variables for the '%s' template (version %s).\n", m.Name, m.TemplateVersion)
+	for _, variable := range m.Variables {
+		result += "\n"
+		for _, comment := range scaffoldComments(variable) {
+			result += fmt.Sprintf("# %s\n", comment)
+		}
+
+		value, err := yamlScalarText(scaffoldValue(variable))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate value for variable '%s': %w", variable.Name, err)
+		}
+		result += fmt.Sprintf("%s=%s\n", variable.Name, envQuote(value))
+	}
+	return result, nil
+}
@@ -0,0 +1,40 @@
+package templates
+
+import (
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// IsBinaryContent returns true if a file content is binary, such as images, fonts, archives or compiled files.
+// The content is sniffed, and it is considered text only if its MIME type is 'text/plain' or one of its descendants
+// (e.g. HTML, JSON, XML or source code). Empty files are considered text.
+func IsBinaryContent(content []byte) bool {
+	if len(content) == 0 {
+		return false
+	}
+	for mimeType := mimetype.Detect(content); mimeType != nil; mimeType = mimeType.Parent() {
+		if mimeType.Is("text/plain") {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesAnyGlob returns true if a path, relative to the template directory, matches the glob patterns.
+// The patterns follow the '.gitignore' semantics, see 'IgnoreMatcher'. For example, '*.png' matches 'assets/logo.png',
+// '/assets/*.png' matches 'assets/logo.png' but not 'docs/assets/logo.png', and 'assets/' matches every file inside 'assets'.
+func MatchesAnyGlob(relativePath string, patterns []string) bool {
+	return NewIgnoreMatcher(patterns).Match(relativePath, false)
+}
+
+// ShouldRenderFile decides whether a file should be rendered as a template or copied byte for byte.
+// Files matching 'renderOnlyPaths' are always rendered and files matching 'copyOnlyPaths' are always copied.
+// Other files are rendered unless their content is binary.
+func ShouldRenderFile(relativePath string, content []byte, copyOnlyPaths, renderOnlyPaths []string) bool {
+	if MatchesAnyGlob(relativePath, renderOnlyPaths) {
+		return true
+	}
+	if MatchesAnyGlob(relativePath, copyOnlyPaths) {
+		return false
+	}
+	return !IsBinaryContent(content)
+}
@@ -3,7 +3,8 @@ package templates
 import (
 	"bytes"
 	"fmt"
-	"os"
+	"io/fs"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -29,39 +30,10 @@ func CustomTxtFuncMap(tmpl *template.Template) template.FuncMap {
 	}
 
 	// "toFile" function is a custom function provided by Cloney, which allows users to dynamically
-	// create files from a template. This function has 2 hidden parameters 'templateDir' and 'fileDir',
-	// representing the directory of the template being processed and the directory of the file currently
-	// being processed, respectively. This parameter is utilized to determine the absolute path where the
-	// generated file will be saved, relative to the file being processed.
-	//
-	// During template execution, 'templateDir' and 'fileDir' are automatically injected into the function.
-	funcMap["toFile"] = func(templateDir, fileDir, relativePath, name string, data interface{}) (string, error) {
-		// Execute the template.
-		buf := bytes.NewBuffer(nil)
-		if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
-			return "", err
-		}
-
-		// Calculate the absolute path of the file.
-		absPath := filepath.Join(fileDir, relativePath)
-
-		if !strings.HasPrefix(filepath.ToSlash(absPath), filepath.ToSlash(templateDir)) {
-			return "", fmt.Errorf("cannot create file outside the scope of the template directory: %s", relativePath)
-		}
-
-		// If needed, create the directory where the file will be saved.
-		directory := filepath.Dir(absPath)
-		if err := os.MkdirAll(directory, os.ModePerm); err != nil {
-			return "", err
-		}
-
-		// Create the file.
-		err := os.WriteFile(absPath, buf.Bytes(), os.ModePerm)
-		if err != nil {
-			return "", err
-		}
-
-		return "", nil
+	// create files from a template. It is replaced for each file being rendered by 'FillDirectory',
+	// which binds it to the directory of the file. See 'toFileFunc'.
+	funcMap["toFile"] = func(relativePath, name string, data interface{}) (string, error) {
+		return "", fmt.Errorf("the 'toFile' function can only be used when filling a template directory")
 	}
 
 	// "os" function is a custom function provided by Cloney, which returns the user's operating system.
@@ -100,3 +72,32 @@ func CustomTxtFuncMap(tmpl *template.Template) template.FuncMap {
 
 	return funcMap
 }
+
+// toFileFunc returns the "toFile" function bound to the file currently being rendered.
+//
+// The function executes the template 'name' with 'data' and passes the result to 'writeFile', along with
+// the name of the new file. The path is relative to 'fileDir', the slash-separated directory of the file being rendered,
+// and must stay inside the template directory.
+// Since the directory is bound here, 'toFile' works in every syntactic position, such as pipelines and multi-line actions.
+func toFileFunc(tmpl *template.Template, fileDir string, writeFile func(name string, content []byte) error) func(string, string, interface{}) (string, error) {
+	return func(relativePath, name string, data interface{}) (string, error) {
+		// Execute the template.
+		buf := bytes.NewBuffer(nil)
+		if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
+			return "", err
+		}
+
+		// Calculate the path of the file, relative to the template directory.
+		// When writing to disk, the path is checked again with 'EnsureInsideDirectory', which also resolves symbolic links.
+		filePath := path.Join(fileDir, filepath.ToSlash(relativePath))
+		if filepath.IsAbs(relativePath) || !fs.ValidPath(filePath) || filePath == "." {
+			return "", fmt.Errorf("cannot create file outside the scope of the template directory: %s", relativePath)
+		}
+
+		if err := writeFile(filePath, buf.Bytes()); err != nil {
+			return "", err
+		}
+
+		return "", nil
+	}
+}
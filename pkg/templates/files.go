@@ -1,12 +1,14 @@
 package templates
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
 	"github.com/fsnotify/fsnotify"
 )
 
@@ -16,13 +18,14 @@ import (
 //
 // Parameters:
 //
+//	ctx:          The context that stops the monitoring when cancelled.
 //	watcher:      An initialized fsnotify.Watcher.
 //	directoryPath: The path of the directory to watch.
 //	ignorePaths:  An optional list of file paths to ignore during monitoring.
 //	onChange:     A callback function to execute when any valid event is detected.
 //
-// Returns an error if any issues occur during setup or monitoring.
-func WatchDirectory(watcher *fsnotify.Watcher, directoryPath string, ignorePaths []string, onChange func()) error {
+// Returns an error if any issues occur during setup or monitoring, and nil once the context is cancelled.
+func WatchDirectory(ctx context.Context, watcher *fsnotify.Watcher, directoryPath string, ignorePaths []string, onChange func()) error {
 	// Get a list of all files in the specified directory, considering ignore options.
 	dirPaths, err := GetAllDirectoryPaths(directoryPath, ignorePaths)
 	if err != nil {
@@ -37,13 +40,13 @@ func WatchDirectory(watcher *fsnotify.Watcher, directoryPath string, ignorePaths
 		}
 	}
 
-	// Create a channel to receive events.
-	done := make(chan bool)
-
-	// Start a goroutine to receive events.
+	// Start a goroutine to receive events, until the context is cancelled.
 	go func() {
 		for {
 			select {
+			case <-ctx.Done():
+				return
+
 			case event := <-watcher.Events:
 				// Check if the event is a create, write, remove, rename, or chmod event.
 				if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename|fsnotify.Chmod) != 0 {
@@ -57,13 +60,15 @@ func WatchDirectory(watcher *fsnotify.Watcher, directoryPath string, ignorePaths
 		}
 	}()
 
-	<-done
+	<-ctx.Done()
 
 	return nil
 }
 
 // CopyDirectory copies a directory recursively
 // with options to specify directories and files to ignore.
+// File modes are preserved, with the user's umask respected, and symbolic links are
+// reproduced as symbolic links, as long as their targets stay inside the directory.
 func CopyDirectory(src string, dest string, ignorePaths []string) error {
 	// Get a list of all files in the specified directory, considering ignore options.
 	filePaths, err := GetAllFilePaths(src, ignorePaths)
@@ -72,15 +77,21 @@ func CopyDirectory(src string, dest string, ignorePaths []string) error {
 	}
 
 	// Iterate over each file in the directory and copy it.
+	progress := terminal.NewProgress("Copying files", len(filePaths))
+	defer progress.Done()
 	for _, filePath := range filePaths {
+		progress.Increment()
 		// Get the relative path of the file.
 		relativePath, err := filepath.Rel(src, filePath)
 		if err != nil {
 			return fmt.Errorf("error getting relative path of file %s: %w", filePath, err)
 		}
 
-		// Construct the destination path.
+		// Construct the destination path, which must stay inside the destination directory.
 		destPath := filepath.Join(dest, relativePath)
+		if err := EnsureInsideDirectory(dest, destPath); err != nil {
+			return fmt.Errorf("error copying file %s: %w", filePath, err)
+		}
 
 		// Create the destination directory if it does not exist.
 		err = os.MkdirAll(filepath.Dir(destPath), os.ModePerm)
@@ -88,14 +99,34 @@ func CopyDirectory(src string, dest string, ignorePaths []string) error {
 			return fmt.Errorf("error creating directory %s: %w", filepath.Dir(destPath), err)
 		}
 
+		// Get the file information without following symbolic links.
+		info, err := os.Lstat(filePath)
+		if err != nil {
+			return fmt.Errorf("error reading file information of %s: %w", filePath, err)
+		}
+
+		// Symbolic links are recreated with the same target, which is relative to the link.
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := CheckSymlinkTarget(src, filePath)
+			if err != nil {
+				return err
+			}
+			os.Remove(destPath)
+			err = os.Symlink(target, destPath)
+			if err != nil {
+				return fmt.Errorf("error creating symbolic link %s: %w", destPath, err)
+			}
+			continue
+		}
+
 		// Read the file content.
 		fileContent, err := os.ReadFile(filePath)
 		if err != nil {
 			return fmt.Errorf("error reading file %s: %w", filePath, err)
 		}
 
-		// Write the file content to the destination.
-		err = os.WriteFile(destPath, fileContent, os.ModePerm)
+		// Write the file content to the destination, with the same permissions as the source file.
+		err = os.WriteFile(destPath, fileContent, info.Mode().Perm())
 		if err != nil {
 			return fmt.Errorf("error copying file %s: %w", filePath, err)
 		}
@@ -104,25 +135,53 @@ func CopyDirectory(src string, dest string, ignorePaths []string) error {
 	return nil
 }
 
+// IsSymlink returns true if a path is a symbolic link. The link itself is checked, not its target.
+func IsSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// CheckSymlinkTarget reads the target of a symbolic link and checks that it stays inside the base directory.
+// Only relative targets are allowed, since absolute targets would not point to the generated files.
+// It returns the target as it is stored in the link.
+func CheckSymlinkTarget(baseDirectory, linkPath string) (string, error) {
+	target, err := os.Readlink(linkPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading symbolic link %s: %w", linkPath, err)
+	}
+
+	if filepath.IsAbs(target) {
+		return "", fmt.Errorf("symbolic link %s has an absolute target '%s', only relative targets are allowed", linkPath, target)
+	}
+
+	// Resolve the target relative to the directory of the link, and check that it does not leave the base directory.
+	resolvedTarget := filepath.Join(filepath.Dir(linkPath), target)
+	if err := EnsureInsideDirectory(baseDirectory, resolvedTarget); err != nil {
+		return "", fmt.Errorf("symbolic link %s points outside the template directory: %s", linkPath, target)
+	}
+
+	return target, nil
+}
+
 // ShouldIgnorePath determines whether a given file or directory path should be ignored
 // based on a list of patterns within a specified base directory. It returns true if the
-// path should be ignored according to any of the provided patterns, and false otherwise.
+// path should be ignored according to the patterns, and false otherwise.
+// The patterns follow the '.gitignore' semantics, see 'IgnoreMatcher'.
 func ShouldIgnorePath(baseDirectory string, path string, ignorePaths []string) (bool, error) {
-	for _, ignorePath := range ignorePaths {
-		// Construct the full path for comparison.
-		fullIgnorePath := filepath.Join(baseDirectory, ignorePath)
-		if os.PathSeparator == '\\' {
-			fullIgnorePath = filepath.ToSlash(fullIgnorePath)
-			path = filepath.ToSlash(path)
-		}
-		// Replace * with .* to allow for regex matching.
-		fullIgnorePath = strings.ReplaceAll(fullIgnorePath, "*", ".*")
-		regex := regexp.MustCompile(fullIgnorePath)
-		if regex.MatchString(path) {
-			return true, nil
-		}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, err
 	}
-	return false, nil
+	return shouldIgnore(NewIgnoreMatcher(ignorePaths), baseDirectory, path, info.IsDir())
+}
+
+// shouldIgnore checks a path against an IgnoreMatcher, relative to the base directory.
+func shouldIgnore(matcher *IgnoreMatcher, baseDirectory string, path string, isDir bool) (bool, error) {
+	relativePath, err := filepath.Rel(baseDirectory, path)
+	if err != nil {
+		return false, err
+	}
+	return matcher.Match(relativePath, isDir), nil
 }
 
 // GetAllFilePaths returns a list of all file paths within a directory and its subdirectories,
@@ -130,6 +189,9 @@ func ShouldIgnorePath(baseDirectory string, path string, ignorePaths []string) (
 func GetAllFilePaths(directoryPath string, ignorePaths []string) ([]string, error) {
 	var filePaths []string
 
+	// Compile the ignore patterns once for the whole walk.
+	matcher := NewIgnoreMatcher(ignorePaths)
+
 	// Walk the directory and its subdirectories.
 	err := filepath.Walk(directoryPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -142,7 +204,7 @@ func GetAllFilePaths(directoryPath string, ignorePaths []string) ([]string, erro
 		}
 
 		// Check if the path should be ignored.
-		ignore, err := ShouldIgnorePath(directoryPath, path, ignorePaths)
+		ignore, err := shouldIgnore(matcher, directoryPath, path, info.IsDir())
 		if err != nil {
 			return fmt.Errorf("error checking if path %s should be ignored: %w", path, err)
 		}
@@ -173,6 +235,9 @@ func GetAllFilePaths(directoryPath string, ignorePaths []string) ([]string, erro
 func GetAllDirectoryPaths(directoryPath string, ignorePaths []string) ([]string, error) {
 	var directoryPaths []string
 
+	// Compile the ignore patterns once for the whole walk.
+	matcher := NewIgnoreMatcher(ignorePaths)
+
 	// Walk the directory and its subdirectories.
 	err := filepath.Walk(directoryPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -185,7 +250,7 @@ func GetAllDirectoryPaths(directoryPath string, ignorePaths []string) ([]string,
 		}
 
 		// Check if the path should be ignored.
-		ignore, err := ShouldIgnorePath(directoryPath, path, ignorePaths)
+		ignore, err := shouldIgnore(matcher, directoryPath, path, info.IsDir())
 		if err != nil {
 			return fmt.Errorf("error checking if path %s should be ignored: %w", path, err)
 		}
@@ -212,6 +277,9 @@ func GetAllDirectoryPaths(directoryPath string, ignorePaths []string) ([]string,
 // Additionally, it deletes files and directories starting with "_" (Ignore Prefix), which are files that should
 // be processed by Cloney but not copied when the template is cloned.
 func DeleteIgnoredFiles(directoryPath string, ignorePaths []string) error {
+	// Compile the ignore patterns once for the whole walk.
+	matcher := NewIgnoreMatcher(ignorePaths)
+
 	// Walk the directory and its subdirectories.
 	err := filepath.Walk(directoryPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -230,13 +298,18 @@ func DeleteIgnoredFiles(directoryPath string, ignorePaths []string) error {
 
 		// Check if the path should be ignored.
 		if !delete {
-			delete, err = ShouldIgnorePath(directoryPath, path, ignorePaths)
+			delete, err = shouldIgnore(matcher, directoryPath, path, info.IsDir())
 			if err != nil {
 				return fmt.Errorf("error checking if path %s should be ignored: %w", path, err)
 			}
 		}
 
 		// If the path should be ignored, delete it.
+		if delete {
+			if relativePath, err := filepath.Rel(directoryPath, path); err == nil {
+				terminal.DebugMessage(fmt.Sprintf("Removed '%s': it starts with '%s' or matches an ignore pattern", filepath.ToSlash(relativePath), appConfig.IgnorePrefix))
+			}
+		}
 		if info.IsDir() && delete {
 			err := os.RemoveAll(path)
 			// Check if the error is due to the directory not existing.
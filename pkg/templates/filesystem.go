@@ -0,0 +1,299 @@
+package templates
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing/fstest"
+)
+
+// WritableFS is a filesystem that template files are rendered into.
+// It is read with the 'io/fs' interfaces and written with the methods below.
+// Names are slash-separated paths relative to the root of the filesystem, as in 'io/fs'.
+type WritableFS interface {
+	fs.FS
+
+	// WriteFile creates or truncates a file, creating its parent directories if needed.
+	// The permissions are only used if the file does not exist yet, and the user's umask may apply.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+
+	// Chmod changes the permissions of a file to exactly the given permissions.
+	Chmod(name string, perm fs.FileMode) error
+
+	// Symlink creates a symbolic link named 'name' pointing to 'target', replacing any existing file.
+	Symlink(target, name string) error
+}
+
+// ReadLinkFS is a filesystem that can read the targets of symbolic links.
+type ReadLinkFS interface {
+	fs.FS
+
+	// ReadLink returns the target of a symbolic link.
+	ReadLink(name string) (string, error)
+}
+
+// DiskFS is a WritableFS backed by a directory on disk.
+// Every write goes through 'EnsureInsideDirectory', so nothing can be written outside the root directory.
+type DiskFS struct {
+	// root is the directory on disk.
+	root string
+
+	// dirFS is used to read the directory with the 'io/fs' interfaces.
+	dirFS fs.FS
+}
+
+// NewDiskFS creates a new DiskFS rooted at the given directory.
+func NewDiskFS(root string) *DiskFS {
+	return &DiskFS{
+		root:  root,
+		dirFS: os.DirFS(root),
+	}
+}
+
+// Open opens a file for reading. It implements fs.FS.
+func (d *DiskFS) Open(name string) (fs.File, error) {
+	return d.dirFS.Open(name)
+}
+
+// ReadFile reads a whole file. It implements fs.ReadFileFS.
+func (d *DiskFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(d.dirFS, name)
+}
+
+// ReadLink returns the target of a symbolic link. It implements ReadLinkFS.
+func (d *DiskFS) ReadLink(name string) (string, error) {
+	fullPath, err := d.fullPath(name)
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(fullPath)
+}
+
+// WriteFile creates or truncates a file. It implements WritableFS.
+func (d *DiskFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	fullPath, err := d.writablePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating directory %s: %w", filepath.Dir(fullPath), err)
+	}
+	if err := os.WriteFile(fullPath, data, perm); err != nil {
+		return fmt.Errorf("error writing file %s: %w", fullPath, err)
+	}
+	return nil
+}
+
+// Chmod changes the permissions of a file. It implements WritableFS.
+func (d *DiskFS) Chmod(name string, perm fs.FileMode) error {
+	fullPath, err := d.writablePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(fullPath, perm); err != nil {
+		return fmt.Errorf("error changing the mode of file %s: %w", fullPath, err)
+	}
+	return nil
+}
+
+// Symlink creates a symbolic link. It implements WritableFS.
+func (d *DiskFS) Symlink(target, name string) error {
+	fullPath, err := d.writablePath(name)
+	if err != nil {
+		return err
+	}
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("symbolic link %s has an absolute target '%s', only relative targets are allowed", fullPath, target)
+	}
+	if err := EnsureInsideDirectory(d.root, filepath.Join(filepath.Dir(fullPath), target)); err != nil {
+		return fmt.Errorf("symbolic link %s points outside the directory: %w", fullPath, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating directory %s: %w", filepath.Dir(fullPath), err)
+	}
+	os.Remove(fullPath)
+	if err := os.Symlink(target, fullPath); err != nil {
+		return fmt.Errorf("error creating symbolic link %s: %w", fullPath, err)
+	}
+	return nil
+}
+
+// fullPath converts a slash-separated name into a path on disk.
+func (d *DiskFS) fullPath(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(d.root, filepath.FromSlash(name)), nil
+}
+
+// writablePath converts a slash-separated name into a path on disk, checking that it stays inside the root directory.
+func (d *DiskFS) writablePath(name string) (string, error) {
+	fullPath, err := d.fullPath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := EnsureInsideDirectory(d.root, fullPath); err != nil {
+		return "", err
+	}
+	return fullPath, nil
+}
+
+// MemoryFS is a WritableFS kept in memory. It is useful to render templates for previews,
+// diffs and tests, and to commit the result to disk only once rendering succeeded.
+type MemoryFS struct {
+	// files holds the files and symbolic links. Directories are implied by the file names.
+	files fstest.MapFS
+
+	// exactModes is the set of files whose permissions were set with 'Chmod', so they are kept exactly when committed.
+	exactModes map[string]bool
+}
+
+// NewMemoryFS creates a new empty MemoryFS.
+func NewMemoryFS() *MemoryFS {
+	return &MemoryFS{
+		files:      make(fstest.MapFS),
+		exactModes: make(map[string]bool),
+	}
+}
+
+// Open opens a file for reading. It implements fs.FS.
+func (m *MemoryFS) Open(name string) (fs.File, error) {
+	return m.files.Open(name)
+}
+
+// ReadFile reads a whole file. It implements fs.ReadFileFS.
+func (m *MemoryFS) ReadFile(name string) ([]byte, error) {
+	return m.files.ReadFile(name)
+}
+
+// ReadLink returns the target of a symbolic link. It implements ReadLinkFS.
+func (m *MemoryFS) ReadLink(name string) (string, error) {
+	file, ok := m.files[name]
+	if !ok || file.Mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return string(file.Data), nil
+}
+
+// WriteFile creates or truncates a file. It implements WritableFS.
+func (m *MemoryFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	if file, ok := m.files[name]; ok && file.Mode&fs.ModeSymlink == 0 {
+		perm = file.Mode.Perm()
+	}
+	m.files[name] = &fstest.MapFile{Data: append([]byte(nil), data...), Mode: perm.Perm()}
+	return nil
+}
+
+// Chmod changes the permissions of a file. It implements WritableFS.
+func (m *MemoryFS) Chmod(name string, perm fs.FileMode) error {
+	file, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+	file.Mode = file.Mode&^fs.ModePerm | perm.Perm()
+	m.exactModes[name] = true
+	return nil
+}
+
+// Symlink creates a symbolic link. It implements WritableFS.
+func (m *MemoryFS) Symlink(target, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "symlink", Path: name, Err: fs.ErrInvalid}
+	}
+	m.files[name] = &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink | 0777}
+	return nil
+}
+
+// Remove deletes a file or symbolic link, if it exists.
+func (m *MemoryFS) Remove(name string) {
+	delete(m.files, name)
+	delete(m.exactModes, name)
+}
+
+// Names returns the names of all files and symbolic links, sorted.
+func (m *MemoryFS) Names() []string {
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSymlink returns true if the file with the given name is a symbolic link.
+func (m *MemoryFS) IsSymlink(name string) bool {
+	file, ok := m.files[name]
+	return ok && file.Mode&fs.ModeSymlink != 0
+}
+
+// CommitTo writes all files and symbolic links to another WritableFS, such as a DiskFS.
+func (m *MemoryFS) CommitTo(output WritableFS) error {
+	for _, name := range m.Names() {
+		file := m.files[name]
+		if file.Mode&fs.ModeSymlink != 0 {
+			if err := output.Symlink(string(file.Data), name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := output.WriteFile(name, file.Data, file.Mode.Perm()); err != nil {
+			return err
+		}
+		if m.exactModes[name] {
+			if err := output.Chmod(name, file.Mode.Perm()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveLinkTarget returns the name a symbolic link points to, relative to the root of the filesystem.
+// It returns an error if the target is absolute or leaves the root.
+func resolveLinkTarget(name, target string) (string, error) {
+	if path.IsAbs(filepath.ToSlash(target)) || filepath.IsAbs(target) {
+		return "", fmt.Errorf("symbolic link %s has an absolute target '%s', only relative targets are allowed", name, target)
+	}
+	resolvedTarget := path.Join(path.Dir(name), filepath.ToSlash(target))
+	if !fs.ValidPath(resolvedTarget) {
+		return "", fmt.Errorf("symbolic link %s points outside the template directory: %s", name, target)
+	}
+	return resolvedTarget, nil
+}
+
+// ListFiles returns the sorted names of all files and symbolic links of a filesystem, skipping the paths matching 'ignorePaths'.
+func ListFiles(source fs.FS, ignorePaths []string) ([]string, error) {
+	matcher := NewIgnoreMatcher(ignorePaths)
+
+	var names []string
+	err := fs.WalkDir(source, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("error walking path %s: %w", name, err)
+		}
+		if name == "." {
+			return nil
+		}
+		if matcher.Match(name, entry.IsDir()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
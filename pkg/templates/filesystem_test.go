@@ -0,0 +1,110 @@
+package templates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderIntoMemoryFS tests rendering a template from an in-memory source into a MemoryFS,
+// without touching the disk. It covers rendered files, copied binary files, 'toFile' and file modes.
+func TestRenderIntoMemoryFS(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	source := fstest.MapFS{
+		"README.md":       {Data: []byte("# {{ .name }}"), Mode: 0644},
+		"bin/run.sh":      {Data: []byte("echo {{ .name }}{{ toFile \"../generated.txt\" \"nested\" . }}"), Mode: 0755},
+		"assets/logo.png": {Data: []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x00, '{', '{'}, Mode: 0644},
+		"ignored.txt":     {Data: []byte("{{ .missing"), Mode: 0644},
+		"_defs.txt":       {Data: []byte("{{ define \"nested\" }}generated {{ .name }}{{ end }}"), Mode: 0644},
+	}
+
+	filler := NewTemplateFiller(map[string]interface{}{"name": "cloney"})
+	output := NewMemoryFS()
+	err := filler.Render(context.Background(), source, []string{"ignored.txt"}, output)
+	assert.NoError(err)
+
+	// Assert that the files were rendered, copied or created as expected.
+	content, err := output.ReadFile("README.md")
+	assert.NoError(err)
+	assert.Equal("# cloney", string(content))
+	content, err = output.ReadFile("bin/run.sh")
+	assert.NoError(err)
+	assert.Equal("echo cloney", string(content))
+	content, err = output.ReadFile("generated.txt")
+	assert.NoError(err)
+	assert.Equal("generated cloney", string(content))
+	content, err = output.ReadFile("assets/logo.png")
+	assert.NoError(err)
+	assert.Equal(source["assets/logo.png"].Data, content)
+	_, err = output.ReadFile("ignored.txt")
+	assert.Error(err)
+
+	// Assert that the file modes were preserved.
+	info, err := output.Open("bin/run.sh")
+	assert.NoError(err)
+	stat, err := info.Stat()
+	assert.NoError(err)
+	assert.Equal("-rwxr-xr-x", stat.Mode().String())
+}
+
+// TestRenderWithToFileOutsideTemplate tests that 'toFile' cannot create files outside the template directory.
+func TestRenderWithToFileOutsideTemplate(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	source := fstest.MapFS{
+		"sub/main.txt": {Data: []byte("{{ toFile \"../../evil.txt\" \"nested\" . }}{{ define \"nested\" }}x{{ end }}")},
+	}
+
+	err := NewTemplateFiller(nil).Render(context.Background(), source, nil, NewMemoryFS())
+	assert.Error(err)
+	assert.Contains(err.Error(), "outside the scope of the template directory")
+}
+
+// TestRenderDirectoryKeepsPreviousOutputOnFailure tests that the destination directory is only replaced once every file
+// was written, so that a failure while writing the files keeps the previous output.
+func TestRenderDirectoryKeepsPreviousOutputOnFailure(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	// The file created with 'toFile' is inside 'README.md', which is a file, so it cannot be written.
+	source := t.TempDir()
+	err := os.WriteFile(filepath.Join(source, "README.md"), []byte("# {{ .name }}"), os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join(source, "_defs.txt"), []byte("{{ define \"nested\" }}nested{{ end }}{{ toFile \"README.md/nested.txt\" \"nested\" . }}"), os.ModePerm)
+	assert.NoError(err)
+
+	dest := filepath.Join(t.TempDir(), "output")
+	err = os.MkdirAll(dest, os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join(dest, "previous.txt"), []byte("previous"), os.ModePerm)
+	assert.NoError(err)
+
+	filler := NewTemplateFiller(map[string]interface{}{"name": "cloney"})
+	err = filler.RenderDirectory(context.Background(), source, dest, nil)
+	assert.Error(err)
+
+	// Assert that the previous output was kept, and that nothing was left next to it.
+	content, err := os.ReadFile(filepath.Join(dest, "previous.txt"))
+	assert.NoError(err)
+	assert.Equal("previous", string(content))
+	entries, err := os.ReadDir(filepath.Dir(dest))
+	assert.NoError(err)
+	assert.Len(entries, 1)
+
+	// Remove the file that cannot be written, and assert that the output is replaced.
+	err = os.Remove(filepath.Join(source, "_defs.txt"))
+	assert.NoError(err)
+	err = filler.RenderDirectory(context.Background(), source, dest, nil)
+	assert.NoError(err)
+	content, err = os.ReadFile(filepath.Join(dest, "README.md"))
+	assert.NoError(err)
+	assert.Equal("# cloney", string(content))
+	assert.NoFileExists(filepath.Join(dest, "previous.txt"))
+}
@@ -0,0 +1,202 @@
+package templates
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule represents a single pattern of an ignore list, compiled to a regular expression.
+type ignoreRule struct {
+	// regex is the regular expression equivalent to the pattern, matched against slash-separated relative paths.
+	regex *regexp.Regexp
+
+	// negate is true if the pattern starts with '!', in which case matching paths are re-included.
+	negate bool
+
+	// dirOnly is true if the pattern ends with '/', in which case it only matches directories.
+	dirOnly bool
+}
+
+// IgnoreMatcher matches paths against a list of patterns following the '.gitignore' semantics:
+//
+//   - Blank lines and lines starting with '#' are ignored. Use '\#' for patterns starting with '#'.
+//   - A pattern starting with '!' re-includes paths excluded by a previous pattern. Use '\!' for patterns starting with '!'.
+//   - A pattern ending with '/' only matches directories.
+//   - A pattern with a '/' at the beginning or in the middle is relative to the template directory.
+//     Otherwise, it matches at any depth.
+//   - '*' matches anything except '/', '?' matches any single character except '/', and '[...]' matches a character range.
+//   - '**' matches any number of directories, as in '**/logs', 'logs/**' and 'a/**/b'.
+//
+// As in Git, the last matching pattern wins, and a path inside an ignored directory is always ignored.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+// NewIgnoreMatcher creates a new IgnoreMatcher from a list of patterns.
+// Invalid patterns, such as unterminated character ranges, are skipped.
+func NewIgnoreMatcher(patterns []string) *IgnoreMatcher {
+	matcher := &IgnoreMatcher{}
+	for _, pattern := range patterns {
+		if rule, ok := compileIgnorePattern(pattern); ok {
+			matcher.rules = append(matcher.rules, rule)
+		}
+	}
+	return matcher
+}
+
+// compileIgnorePattern compiles a single pattern. It returns false if the pattern is empty, a comment or invalid.
+func compileIgnorePattern(pattern string) (ignoreRule, bool) {
+	var rule ignoreRule
+
+	// Trailing spaces are ignored, unless they are escaped with a backslash.
+	pattern = strings.TrimRight(strings.TrimSuffix(pattern, "\r"), " ")
+	if strings.HasSuffix(pattern, "\\") {
+		pattern += " "
+	}
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return rule, false
+	}
+
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, "\\!") || strings.HasPrefix(pattern, "\\#") {
+		pattern = pattern[1:]
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if pattern == "" {
+		return rule, false
+	}
+
+	// Patterns with a '/' at the beginning or in the middle are anchored to the template directory.
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expression strings.Builder
+	expression.WriteString("^")
+	if !anchored && !strings.HasPrefix(pattern, "**") {
+		expression.WriteString("(?:.*/)?")
+	}
+
+	for index := 0; index < len(pattern); index++ {
+		char := pattern[index]
+		switch {
+		case strings.HasPrefix(pattern[index:], "**/"):
+			// Leading or middle '**/' matches zero or more directories.
+			if index == 0 || pattern[index-1] == '/' {
+				expression.WriteString("(?:.*/)?")
+				index += 2
+			} else {
+				expression.WriteString("[^/]*")
+				index++
+			}
+		case strings.HasPrefix(pattern[index:], "**") && index+2 == len(pattern) && (index == 0 || pattern[index-1] == '/'):
+			// Trailing '/**' matches everything inside a directory.
+			expression.WriteString(".*")
+			index++
+		case char == '*':
+			expression.WriteString("[^/]*")
+		case char == '?':
+			expression.WriteString("[^/]")
+		case char == '[':
+			end := strings.IndexByte(pattern[index+1:], ']')
+			if end == -1 {
+				return rule, false
+			}
+			class := pattern[index+1 : index+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression.WriteString("[" + strings.ReplaceAll(class, "\\", "\\\\") + "]")
+			index += end + 1
+		case char == '\\' && index+1 < len(pattern):
+			expression.WriteString(regexp.QuoteMeta(string(pattern[index+1])))
+			index++
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	expression.WriteString("$")
+
+	regex, err := regexp.Compile(expression.String())
+	if err != nil {
+		return rule, false
+	}
+	rule.regex = regex
+	return rule, true
+}
+
+// matchPath returns whether a single path is ignored by the rules, without checking its parent directories.
+func (m *IgnoreMatcher) matchPath(relativePath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.regex.MatchString(relativePath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// Match returns true if a path, relative to the template directory, is ignored.
+// 'isDir' indicates whether the path is a directory, which is needed for patterns ending with '/'.
+func (m *IgnoreMatcher) Match(relativePath string, isDir bool) bool {
+	relativePath = strings.Trim(filepath.ToSlash(relativePath), "/")
+	if relativePath == "" || relativePath == "." || len(m.rules) == 0 {
+		return false
+	}
+
+	// A path inside an ignored directory is always ignored.
+	segments := strings.Split(relativePath, "/")
+	for index := 1; index < len(segments); index++ {
+		if m.matchPath(strings.Join(segments[:index], "/"), true) {
+			return true
+		}
+	}
+
+	return m.matchPath(relativePath, isDir)
+}
+
+// AnchoredPattern returns a pattern matching exactly one path, relative to the template directory,
+// escaping the characters that have a special meaning in patterns.
+func AnchoredPattern(relativePath string) string {
+	var pattern strings.Builder
+	pattern.WriteString("/")
+	for _, char := range strings.Trim(filepath.ToSlash(relativePath), "/") {
+		if strings.ContainsRune("*?[]\\!# ", char) {
+			pattern.WriteRune('\\')
+		}
+		pattern.WriteRune(char)
+	}
+	return pattern.String()
+}
+
+// ReadIgnoreFile reads the patterns of an ignore file, such as '.cloneyignore'.
+// It returns an empty list if the file does not exist.
+func ReadIgnoreFile(filePath string) ([]string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	return patterns, scanner.Err()
+}
@@ -0,0 +1,155 @@
+package templates
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+// LayeredFS is a read-only filesystem made of several layers, such as a template and the base templates it extends.
+// A file of an upper layer hides the file with the same path in the lower layers, and directories are merged.
+type LayeredFS struct {
+	// layers are the filesystems, from the upper to the lower layer.
+	layers []fs.FS
+}
+
+// NewLayeredFS creates a new LayeredFS from a list of filesystems, from the upper to the lower layer.
+func NewLayeredFS(layers ...fs.FS) *LayeredFS {
+	return &LayeredFS{layers: layers}
+}
+
+// Open opens a file from the upper layer that has it. It implements fs.FS.
+func (l *LayeredFS) Open(name string) (fs.File, error) {
+	layer := l.LayerOf(name)
+	if layer < 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return l.layers[layer].Open(name)
+}
+
+// ReadDir reads a directory, merging the entries of all layers, sorted by name.
+// An entry of an upper layer hides the entry with the same name in the lower layers. It implements fs.ReadDirFS.
+func (l *LayeredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var firstErr error
+	found := false
+	entriesByName := make(map[string]fs.DirEntry)
+	for _, layer := range l.layers {
+		entries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			if firstErr == nil && !errors.Is(err, fs.ErrNotExist) {
+				firstErr = err
+			}
+			continue
+		}
+		found = true
+		for _, entry := range entries {
+			if _, ok := entriesByName[entry.Name()]; !ok {
+				entriesByName[entry.Name()] = entry
+			}
+		}
+	}
+	if !found {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(entriesByName))
+	for _, entry := range entriesByName {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// ReadLink returns the target of a symbolic link from the upper layer that has it. It implements ReadLinkFS.
+func (l *LayeredFS) ReadLink(name string) (string, error) {
+	layer := l.LayerOf(name)
+	if layer < 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	linkFS, ok := l.layers[layer].(ReadLinkFS)
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return linkFS.ReadLink(name)
+}
+
+// LayerOf returns the index of the upper layer that has a file, starting at 0, or -1 if no layer has it.
+// Symbolic links are found even if their targets do not exist.
+func (l *LayeredFS) LayerOf(name string) int {
+	for index, layer := range l.layers {
+		if _, err := fs.Stat(layer, name); err == nil {
+			return index
+		}
+		if linkFS, ok := layer.(ReadLinkFS); ok {
+			if _, err := linkFS.ReadLink(name); err == nil {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// FilteredFS is a read-only filesystem hiding the files and directories of another filesystem that match ignore patterns,
+// such as the files ignored by one of several composed templates, so that they do not hide the files of the lower layers.
+type FilteredFS struct {
+	// fsys is the filtered filesystem.
+	fsys fs.FS
+
+	// matcher matches the hidden paths.
+	matcher *IgnoreMatcher
+}
+
+// NewFilteredFS creates a new FilteredFS hiding the paths of a filesystem that match 'ignorePaths'.
+func NewFilteredFS(fsys fs.FS, ignorePaths []string) *FilteredFS {
+	return &FilteredFS{fsys: fsys, matcher: NewIgnoreMatcher(ignorePaths)}
+}
+
+// hidden returns true if a path is ignored. Paths that cannot be found are not hidden, so that their errors are kept.
+func (f *FilteredFS) hidden(name string) bool {
+	info, err := fs.Stat(f.fsys, name)
+	return f.matcher.Match(name, err == nil && info.IsDir())
+}
+
+// Open opens a file, unless it is hidden. It implements fs.FS.
+func (f *FilteredFS) Open(name string) (fs.File, error) {
+	if f.hidden(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.fsys.Open(name)
+}
+
+// ReadDir reads a directory, without its hidden entries. It implements fs.ReadDirFS.
+func (f *FilteredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if f.hidden(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	visible := entries[:0]
+	for _, entry := range entries {
+		if !f.matcher.Match(path.Join(name, entry.Name()), entry.IsDir()) {
+			visible = append(visible, entry)
+		}
+	}
+	return visible, nil
+}
+
+// ReadLink returns the target of a symbolic link, unless it is hidden. It implements ReadLinkFS.
+func (f *FilteredFS) ReadLink(name string) (string, error) {
+	linkFS, ok := f.fsys.(ReadLinkFS)
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	if f.matcher.Match(name, false) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	return linkFS.ReadLink(name)
+}
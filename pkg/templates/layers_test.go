@@ -0,0 +1,93 @@
+package templates
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLayeredFS tests that the files of an upper layer hide the files of the lower layers, and that directories are merged.
+func TestLayeredFS(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	upper := fstest.MapFS{
+		"README.md":     {Data: []byte("upper")},
+		"src/main.go":   {Data: []byte("upper main")},
+		"docs/guide.md": {Data: []byte("upper guide")},
+	}
+	lower := fstest.MapFS{
+		"README.md":   {Data: []byte("lower")},
+		"LICENSE":     {Data: []byte("lower license")},
+		"src/util.go": {Data: []byte("lower util")},
+	}
+	layered := NewLayeredFS(upper, lower)
+
+	// Assert that the upper files win and that the lower files are inherited.
+	content, err := fs.ReadFile(layered, "README.md")
+	assert.NoError(err)
+	assert.Equal("upper", string(content))
+	content, err = fs.ReadFile(layered, "src/util.go")
+	assert.NoError(err)
+	assert.Equal("lower util", string(content))
+	assert.Equal(0, layered.LayerOf("src/main.go"))
+	assert.Equal(1, layered.LayerOf("LICENSE"))
+	assert.Equal(-1, layered.LayerOf("missing.txt"))
+
+	// Assert that walking the filesystem lists every file once, sorted.
+	var names []string
+	err = fs.WalkDir(layered, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			names = append(names, name)
+		}
+		return err
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"LICENSE", "README.md", "docs/guide.md", "src/main.go", "src/util.go"}, names)
+}
+
+// TestFilteredFSInLayeredFS tests a filtered upper layer, as for composed templates.
+// The files it ignores should be hidden, so that the files of the lower layers with the same path are used.
+func TestFilteredFSInLayeredFS(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	upper := fstest.MapFS{
+		"README.md":      {Data: []byte("upper")},
+		"docs/guide.md":  {Data: []byte("upper guide")},
+		"[draft].md":     {Data: []byte("upper draft")},
+		"notes/todo.txt": {Data: []byte("upper todo")},
+	}
+	lower := fstest.MapFS{
+		"docs/guide.md": {Data: []byte("lower guide")},
+		"[draft].md":    {Data: []byte("lower draft")},
+	}
+	layered := NewLayeredFS(NewFilteredFS(upper, []string{"docs/", AnchoredPattern("[draft].md"), "notes/"}), lower)
+
+	// Assert that the ignored files of the upper layer are hidden.
+	content, err := fs.ReadFile(layered, "docs/guide.md")
+	assert.NoError(err)
+	assert.Equal("lower guide", string(content))
+	content, err = fs.ReadFile(layered, "[draft].md")
+	assert.NoError(err)
+	assert.Equal("lower draft", string(content))
+	assert.Equal(-1, layered.LayerOf("notes/todo.txt"))
+
+	// Assert that walking the filesystem does not list the hidden directories.
+	var names []string
+	err = fs.WalkDir(layered, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			names = append(names, name)
+		}
+		return err
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"README.md", "[draft].md", "docs/guide.md"}, names)
+
+	// Assert that anchored patterns only match their exact path.
+	assert.True(MatchesAnyGlob("[draft].md", []string{AnchoredPattern("[draft].md")}))
+	assert.False(MatchesAnyGlob("d.md", []string{AnchoredPattern("[draft].md")}))
+	assert.False(MatchesAnyGlob("docs/[draft].md", []string{AnchoredPattern("[draft].md")}))
+}
@@ -0,0 +1,350 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// Constants for lint issue severities.
+const (
+	LINT_ERROR_SEVERITY   = "error"
+	LINT_WARNING_SEVERITY = "warning"
+)
+
+// LintIssue represents a problem found in a template repository while linting it.
+type LintIssue struct {
+	// Severity is the severity of the issue, either 'error' or 'warning'.
+	Severity string
+
+	// File is the path of the file where the issue was found.
+	File string
+
+	// Line is the line of the file where the issue was found.
+	// It is 0 if the issue is not related to a specific line.
+	Line int
+
+	// Message describes the issue.
+	Message string
+}
+
+// String returns the string representation of the LintIssue struct.
+func (i LintIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.File, i.Message)
+}
+
+// parseErrorLineRegex is a regular expression to extract the line number of a template parse error.
+var parseErrorLineRegex = regexp.MustCompile(`^:(\d+):\s*(.*)$`)
+
+// templateLinter holds the state collected while walking the parse trees of a template repository.
+type templateLinter struct {
+	// tmpl is the template set containing all the parsed files.
+	tmpl *template.Template
+
+	// fileContents maps each file path to its content, used to calculate line numbers.
+	fileContents map[string]string
+
+	// partials is the set of partial and inherited file names, which are parsed but not linted as files.
+	partials map[string]bool
+
+	// declared is the set of variables declared in the template repository metadata file.
+	declared map[string]bool
+
+	// used is the set of variables referenced at least once in the template files.
+	used map[string]bool
+
+	// usesWholeData is true if the whole variables map is passed to a function,
+	// in which case it is not possible to know which variables are used.
+	usesWholeData bool
+
+	// issues is the list of issues found.
+	issues []LintIssue
+}
+
+// LintDirectory parses every non-ignored file in a template directory with the same functions
+// used by 'FillDirectory' and walks the resulting parse trees looking for problems.
+//
+// It reports parse errors, references to variables not present in 'declaredVariables',
+// declared variables that are never used, and 'include', 'toFile' or 'template' calls
+// to template names that do not exist. Files that are not rendered, such as binary files, are skipped,
+// and each file is parsed with its own action delimiters.
+func LintDirectory(src string, ignorePaths, declaredVariables []string, options RenderOptions) ([]LintIssue, error) {
+	// Get a list of all files in the specified directory, considering ignore options.
+	filePaths, err := GetAllFilePaths(src, ignorePaths)
+	if err != nil {
+		return nil, fmt.Errorf("error obtaining file paths in directory %s: %w", src, err)
+	}
+
+	// Create a template and add custom functions, exactly as when filling the directory.
+	tmpl := template.New("")
+	tmpl.Funcs(sprig.TxtFuncMap())
+	tmpl.Funcs(CustomTxtFuncMap(tmpl))
+
+	linter := &templateLinter{
+		tmpl:         tmpl,
+		fileContents: make(map[string]string),
+		partials:     make(map[string]bool),
+		declared:     make(map[string]bool),
+		used:         make(map[string]bool),
+	}
+	for _, name := range declaredVariables {
+		linter.declared[name] = true
+	}
+
+	// Parse the partials first, as when filling the directory.
+	// Their 'define' blocks are linted, but they are not executed with the variables map.
+	partials, err := readPartials(options.Partials)
+	if err != nil {
+		return nil, err
+	}
+	for _, partial := range partials {
+		linter.fileContents[partial.name] = string(partial.content)
+		linter.partials[partial.name] = true
+
+		delimiters := options.DelimitersFor(partial.name)
+		_, err = tmpl.New(partial.name).Delims(delimiters.Left, delimiters.Right).Parse(string(partial.content))
+		if err != nil {
+			linter.addParseError(partial.name, err)
+		}
+	}
+
+	// Parse the files inherited from the base templates, as when filling the directory.
+	// They are parsed like partials, so that the template can include and override their templates, but they are not linted.
+	if len(options.Bases) > 0 {
+		matcher := NewIgnoreMatcher(ignorePaths)
+		inherited, err := readPartials([]PartialLibrary{{FS: NewLayeredFS(options.Bases...)}})
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range inherited {
+			// Files that are ignored, not rendered or overridden by the template are skipped.
+			if matcher.Match(file.name, false) || !options.ShouldRender(file.name, file.content) {
+				continue
+			}
+			if _, err := os.Lstat(filepath.Join(src, filepath.FromSlash(file.name))); err == nil {
+				continue
+			}
+			linter.fileContents[file.name] = string(file.content)
+			linter.partials[file.name] = true
+
+			delimiters := options.DelimitersFor(file.name)
+			_, err = tmpl.New(file.name).Delims(delimiters.Left, delimiters.Right).Parse(string(file.content))
+			if err != nil {
+				linter.addParseError(file.name, err)
+			}
+		}
+	}
+
+	// Parse all files into the template, collecting parse errors instead of stopping at the first one.
+	for _, filePath := range filePaths {
+		// Symbolic links are not followed.
+		if IsSymlink(filePath) {
+			continue
+		}
+
+		fileBytes, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %s: %w", filePath, err)
+		}
+
+		// Files that are not rendered are not linted.
+		relativePath, _ := filepath.Rel(src, filePath)
+		if !options.ShouldRender(relativePath, fileBytes) {
+			continue
+		}
+		linter.fileContents[filePath] = string(fileBytes)
+
+		delimiters := options.DelimitersFor(relativePath)
+		_, err = tmpl.New(filePath).Delims(delimiters.Left, delimiters.Right).Parse(string(fileBytes))
+		if err != nil {
+			linter.addParseError(filePath, err)
+		}
+	}
+
+	// Walk the parse trees of all templates, including the ones created with 'define'.
+	templates := tmpl.Templates()
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name() < templates[j].Name()
+	})
+	for _, t := range templates {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		// Only files are executed with the variables map as data.
+		// Templates created with 'define' can receive any data, so their references are only counted as usages.
+		_, isFile := linter.fileContents[t.Name()]
+		isFile = isFile && !linter.partials[t.Name()]
+		linter.walk(t.Tree, t.Tree.Root, isFile)
+	}
+
+	// Report declared variables that are never used.
+	if !linter.usesWholeData {
+		for _, name := range declaredVariables {
+			if !linter.used[name] {
+				linter.issues = append(linter.issues, LintIssue{
+					Severity: LINT_WARNING_SEVERITY,
+					File:     appConfig.MetadataFileName,
+					Message:  fmt.Sprintf("variable '%s' is declared but never used", name),
+				})
+			}
+		}
+	}
+
+	// Sort issues by file and line so the output is deterministic.
+	sort.SliceStable(linter.issues, func(i, j int) bool {
+		if linter.issues[i].File != linter.issues[j].File {
+			return linter.issues[i].File < linter.issues[j].File
+		}
+		return linter.issues[i].Line < linter.issues[j].Line
+	})
+
+	return linter.issues, nil
+}
+
+// addParseError adds a parse error issue, extracting the line number from the error message if possible.
+func (l *templateLinter) addParseError(filePath string, err error) {
+	issue := LintIssue{
+		Severity: LINT_ERROR_SEVERITY,
+		File:     filePath,
+		Message:  err.Error(),
+	}
+
+	// Parse errors have the format "template: <name>:<line>: <message>".
+	rest := strings.TrimPrefix(err.Error(), fmt.Sprintf("template: %s", filePath))
+	if matches := parseErrorLineRegex.FindStringSubmatch(rest); matches != nil {
+		issue.Line, _ = strconv.Atoi(matches[1])
+		issue.Message = matches[2]
+	}
+
+	l.issues = append(l.issues, issue)
+}
+
+// lineOf returns the line number of a node position in a file.
+func (l *templateLinter) lineOf(tree *parse.Tree, pos parse.Pos) int {
+	content := l.fileContents[tree.ParseName]
+	if int(pos) > len(content) {
+		return 0
+	}
+	return 1 + strings.Count(content[:pos], "\n")
+}
+
+// addIssue adds an issue related to a node of a parse tree.
+func (l *templateLinter) addIssue(tree *parse.Tree, pos parse.Pos, severity, message string) {
+	l.issues = append(l.issues, LintIssue{
+		Severity: severity,
+		File:     tree.ParseName,
+		Line:     l.lineOf(tree, pos),
+		Message:  message,
+	})
+}
+
+// useVariable registers a reference to a variable, reporting it if it is not declared.
+// 'atRoot' indicates whether the reference is made against the variables map.
+func (l *templateLinter) useVariable(tree *parse.Tree, pos parse.Pos, name string, atRoot bool) {
+	l.used[name] = true
+	if atRoot && !l.declared[name] {
+		l.addIssue(tree, pos, LINT_ERROR_SEVERITY, fmt.Sprintf("variable '%s' is not declared in the metadata file", name))
+	}
+}
+
+// checkTemplateName reports a reference to a template name that does not exist.
+func (l *templateLinter) checkTemplateName(tree *parse.Tree, pos parse.Pos, function, name string) {
+	if l.tmpl.Lookup(name) == nil {
+		l.addIssue(tree, pos, LINT_ERROR_SEVERITY, fmt.Sprintf("'%s' references template '%s', which does not exist", function, name))
+	}
+}
+
+// walk walks a parse tree node. 'atRoot' indicates whether the dot is the variables map at this point.
+func (l *templateLinter) walk(tree *parse.Tree, node parse.Node, atRoot bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.walk(tree, child, atRoot)
+		}
+	case *parse.ActionNode:
+		l.walk(tree, n.Pipe, atRoot)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			l.walk(tree, cmd, atRoot)
+		}
+	case *parse.CommandNode:
+		l.walkCommand(tree, n, atRoot)
+	case *parse.FieldNode:
+		l.useVariable(tree, n.Position(), n.Ident[0], atRoot)
+	case *parse.VariableNode:
+		// '$' refers to the data the template was executed with.
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			l.useVariable(tree, n.Position(), n.Ident[1], true)
+		}
+	case *parse.ChainNode:
+		l.walk(tree, n.Node, atRoot)
+	case *parse.IfNode:
+		l.walk(tree, n.Pipe, atRoot)
+		l.walk(tree, n.List, atRoot)
+		l.walk(tree, n.ElseList, atRoot)
+	case *parse.RangeNode:
+		// Inside 'range' and 'with', the dot changes, except in the 'else' branch.
+		l.walk(tree, n.Pipe, atRoot)
+		l.walk(tree, n.List, false)
+		l.walk(tree, n.ElseList, atRoot)
+	case *parse.WithNode:
+		l.walk(tree, n.Pipe, atRoot)
+		l.walk(tree, n.List, false)
+		l.walk(tree, n.ElseList, atRoot)
+	case *parse.TemplateNode:
+		l.checkTemplateName(tree, n.Position(), "template", n.Name)
+		l.walk(tree, n.Pipe, atRoot)
+	}
+}
+
+// walkCommand walks a command node, checking the template names used by 'include' and 'toFile'.
+func (l *templateLinter) walkCommand(tree *parse.Tree, cmd *parse.CommandNode, atRoot bool) {
+	if len(cmd.Args) == 0 {
+		return
+	}
+
+	function := ""
+	if identifier, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		function = identifier.Ident
+	}
+
+	// The template name is the first argument of 'include' and the second argument of 'toFile'.
+	nameIndex := 0
+	switch function {
+	case "include":
+		nameIndex = 1
+	case "toFile":
+		nameIndex = 2
+	}
+	if nameIndex > 0 && len(cmd.Args) > nameIndex {
+		if name, ok := cmd.Args[nameIndex].(*parse.StringNode); ok {
+			l.checkTemplateName(tree, name.Position(), function, name.Text)
+		}
+	}
+
+	for index, arg := range cmd.Args {
+		// Passing the whole variables map to a function other than 'include' or 'toFile'
+		// makes it impossible to know which variables are used.
+		if _, isDot := arg.(*parse.DotNode); isDot && atRoot && index > 0 && nameIndex == 0 && function != "" {
+			l.usesWholeData = true
+		}
+		l.walk(tree, arg, atRoot)
+	}
+}
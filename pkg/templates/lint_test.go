@@ -0,0 +1,84 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLintTestFiles writes files in a new temporary directory and returns its path.
+func writeLintTestFiles(t *testing.T, files map[string]string) string {
+	directory := t.TempDir()
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(directory, name), []byte(content), os.ModePerm)
+		assert.NoError(t, err)
+	}
+	return directory
+}
+
+// TestLintDirectoryWithSyntaxError tests linting a file with an unclosed action.
+// It should report a single error on the line of the action.
+func TestLintDirectoryWithSyntaxError(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	directory := writeLintTestFiles(t, map[string]string{
+		"broken.txt": "line 1\nline 2\n{{ if .dark_mode }",
+	})
+
+	issues, err := LintDirectory(directory, nil, nil, RenderOptions{})
+	assert.NoError(err)
+	assert.Len(issues, 1)
+	assert.Equal(LINT_ERROR_SEVERITY, issues[0].Severity)
+	assert.Equal(filepath.Join(directory, "broken.txt"), issues[0].File)
+	assert.Equal(3, issues[0].Line)
+}
+
+// TestLintDirectoryWithMissingTemplate tests linting a file including a template that does not exist.
+// It should report a single error on the line of the 'include' call.
+func TestLintDirectoryWithMissingTemplate(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	directory := writeLintTestFiles(t, map[string]string{
+		"include.txt": "line 1\n{{ include \"missing\" . }}",
+	})
+
+	issues, err := LintDirectory(directory, nil, nil, RenderOptions{})
+	assert.NoError(err)
+	assert.Len(issues, 1)
+	assert.Equal(LINT_ERROR_SEVERITY, issues[0].Severity)
+	assert.Equal(filepath.Join(directory, "include.txt"), issues[0].File)
+	assert.Equal(2, issues[0].Line)
+	assert.Contains(issues[0].Message, "'missing'")
+}
+
+// TestLintDirectoryWithUndeclaredAndUnusedVariables tests linting a file referencing an undeclared variable
+// while another variable is declared but never used. It should report an error and a warning.
+func TestLintDirectoryWithUndeclaredAndUnusedVariables(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	directory := writeLintTestFiles(t, map[string]string{
+		"README.md": "# {{ .app_name }}\n\n{{ .undeclared }}",
+	})
+
+	issues, err := LintDirectory(directory, nil, []string{"app_name", "unused"}, RenderOptions{})
+	assert.NoError(err)
+	assert.Len(issues, 2)
+
+	// Issues are sorted by file, so the metadata file warning comes first.
+	assert.Equal(LintIssue{
+		Severity: LINT_WARNING_SEVERITY,
+		File:     appConfig.MetadataFileName,
+		Message:  "variable 'unused' is declared but never used",
+	}, issues[0])
+	assert.Equal(LintIssue{
+		Severity: LINT_ERROR_SEVERITY,
+		File:     filepath.Join(directory, "README.md"),
+		Line:     3,
+		Message:  "variable 'undeclared' is not declared in the metadata file",
+	}, issues[1])
+}
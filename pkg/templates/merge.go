@@ -0,0 +1,196 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Constants for the strategies used when a generated file already exists in the destination directory.
+const (
+	SKIP_CONFLICT_STRATEGY          = "skip"
+	OVERWRITE_CONFLICT_STRATEGY     = "overwrite"
+	PROMPT_CONFLICT_STRATEGY        = "prompt"
+	BACKUP_CONFLICT_STRATEGY        = "backup"
+	MERGE_MARKERS_CONFLICT_STRATEGY = "merge-markers"
+)
+
+// SupportedConflictStrategies is the list of supported conflict strategies.
+var SupportedConflictStrategies = []string{
+	SKIP_CONFLICT_STRATEGY,
+	OVERWRITE_CONFLICT_STRATEGY,
+	PROMPT_CONFLICT_STRATEGY,
+	BACKUP_CONFLICT_STRATEGY,
+	MERGE_MARKERS_CONFLICT_STRATEGY,
+}
+
+// MergeReport lists what happened to each generated file when merging it into an existing directory.
+// Paths are relative to the destination directory. The commands also print it in the 'json' and 'yaml' output formats.
+type MergeReport struct {
+	// Created is the list of files that did not exist and were created.
+	Created []string `json:"created" yaml:"created"`
+
+	// Overwritten is the list of existing files that were replaced.
+	Overwritten []string `json:"overwritten,omitempty" yaml:"overwritten,omitempty"`
+
+	// BackedUp is the list of existing files that were renamed before being replaced, with the name of their backup.
+	BackedUp []string `json:"backed_up,omitempty" yaml:"backed_up,omitempty"`
+
+	// Merged is the list of existing files where both versions were written between conflict markers.
+	Merged []string `json:"merged,omitempty" yaml:"merged,omitempty"`
+
+	// Skipped is the list of existing files that were kept as they are.
+	Skipped []string `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+
+	// Unchanged is the list of existing files whose content was already the same as the generated one.
+	Unchanged []string `json:"unchanged,omitempty" yaml:"unchanged,omitempty"`
+}
+
+// MergeDirectory merges the files of a source directory, such as a staging directory, into an existing destination directory.
+//
+// Files that do not exist in the destination are created. For existing files with a different content, 'strategy' decides:
+// 'skip' keeps the existing file, 'overwrite' replaces it, 'prompt' calls 'confirmOverwrite' to decide, 'backup' renames
+// the existing file to '<name>.bak' before replacing it, and 'merge-markers' writes both versions between Git-style
+// conflict markers. Binary files and symbolic links cannot be merged, so they are skipped with 'merge-markers'.
+func MergeDirectory(src, dest, strategy string, confirmOverwrite func(relativePath string) (bool, error)) (*MergeReport, error) {
+	report := &MergeReport{}
+	destFS := NewDiskFS(dest)
+
+	// Get a list of all files in the source directory.
+	filePaths, err := GetAllFilePaths(src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error obtaining file paths in directory %s: %w", src, err)
+	}
+
+	for _, filePath := range filePaths {
+		relativePath, err := filepath.Rel(src, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error getting relative path of file %s: %w", filePath, err)
+		}
+		name := filepath.ToSlash(relativePath)
+		destPath := filepath.Join(dest, relativePath)
+
+		// Read the generated file, which can be a symbolic link.
+		info, err := os.Lstat(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file information of %s: %w", filePath, err)
+		}
+		isSymlink := info.Mode()&os.ModeSymlink != 0
+		var content []byte
+		var target string
+		if isSymlink {
+			target, err = os.Readlink(filePath)
+		} else {
+			content, err = os.ReadFile(filePath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading file %s: %w", filePath, err)
+		}
+
+		// Files that do not exist yet are created.
+		if _, err := os.Lstat(destPath); err != nil {
+			if err := writeMergedFile(destFS, name, content, target, isSymlink, info.Mode().Perm()); err != nil {
+				return nil, err
+			}
+			report.Created = append(report.Created, relativePath)
+			continue
+		}
+
+		// Existing files with the same content are left as they are.
+		if !isSymlink && !IsSymlink(destPath) {
+			if existingContent, err := os.ReadFile(destPath); err == nil && bytes.Equal(existingContent, content) {
+				report.Unchanged = append(report.Unchanged, relativePath)
+				continue
+			}
+		}
+
+		switch strategy {
+		case SKIP_CONFLICT_STRATEGY:
+			report.Skipped = append(report.Skipped, relativePath)
+		case OVERWRITE_CONFLICT_STRATEGY, PROMPT_CONFLICT_STRATEGY:
+			if strategy == PROMPT_CONFLICT_STRATEGY {
+				overwrite, err := confirmOverwrite(relativePath)
+				if err != nil {
+					return nil, err
+				}
+				if !overwrite {
+					report.Skipped = append(report.Skipped, relativePath)
+					continue
+				}
+			}
+			if err := writeMergedFile(destFS, name, content, target, isSymlink, info.Mode().Perm()); err != nil {
+				return nil, err
+			}
+			report.Overwritten = append(report.Overwritten, relativePath)
+		case BACKUP_CONFLICT_STRATEGY:
+			backupPath := availableBackupPath(destPath)
+			if err := os.Rename(destPath, backupPath); err != nil {
+				return nil, fmt.Errorf("error backing up file %s: %w", destPath, err)
+			}
+			if err := writeMergedFile(destFS, name, content, target, isSymlink, info.Mode().Perm()); err != nil {
+				return nil, err
+			}
+			backupRelativePath, _ := filepath.Rel(dest, backupPath)
+			report.BackedUp = append(report.BackedUp, fmt.Sprintf("%s -> %s", relativePath, backupRelativePath))
+		case MERGE_MARKERS_CONFLICT_STRATEGY:
+			existingContent, err := os.ReadFile(destPath)
+			if isSymlink || IsSymlink(destPath) || err != nil || IsBinaryContent(content) || IsBinaryContent(existingContent) {
+				report.Skipped = append(report.Skipped, relativePath)
+				continue
+			}
+			if err := destFS.WriteFile(name, mergeWithConflictMarkers(existingContent, content), info.Mode().Perm()); err != nil {
+				return nil, err
+			}
+			report.Merged = append(report.Merged, relativePath)
+		default:
+			return nil, fmt.Errorf("unsupported conflict strategy '%s', expected one of: %s", strategy, strings.Join(SupportedConflictStrategies, ", "))
+		}
+	}
+
+	return report, nil
+}
+
+// writeMergedFile writes a generated file or symbolic link to the destination filesystem, replacing any existing file.
+func writeMergedFile(destFS *DiskFS, name string, content []byte, target string, isSymlink bool, perm fs.FileMode) error {
+	if isSymlink {
+		return destFS.Symlink(target, name)
+	}
+
+	// An existing symbolic link is replaced by the file, instead of writing to its target.
+	if fullPath, err := destFS.writablePath(name); err == nil && IsSymlink(fullPath) {
+		os.Remove(fullPath)
+	}
+	return destFS.WriteFile(name, content, perm)
+}
+
+// availableBackupPath returns the first backup path that does not exist yet: '<path>.bak', '<path>.bak.1', and so on.
+func availableBackupPath(path string) string {
+	backupPath := path + ".bak"
+	for index := 1; ; index++ {
+		if _, err := os.Lstat(backupPath); err != nil {
+			return backupPath
+		}
+		backupPath = fmt.Sprintf("%s.bak.%d", path, index)
+	}
+}
+
+// mergeWithConflictMarkers returns both versions of a file between Git-style conflict markers,
+// so that the user can resolve the conflict with the usual tools.
+func mergeWithConflictMarkers(existingContent, generatedContent []byte) []byte {
+	var result bytes.Buffer
+	result.WriteString("<<<<<<< existing\n")
+	result.Write(existingContent)
+	if len(existingContent) > 0 && !bytes.HasSuffix(existingContent, []byte("\n")) {
+		result.WriteString("\n")
+	}
+	result.WriteString("=======\n")
+	result.Write(generatedContent)
+	if len(generatedContent) > 0 && !bytes.HasSuffix(generatedContent, []byte("\n")) {
+		result.WriteString("\n")
+	}
+	result.WriteString(">>>>>>> template\n")
+	return result.Bytes()
+}
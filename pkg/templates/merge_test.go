@@ -0,0 +1,79 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createMergeDirectories creates a source directory with generated files and a destination directory with existing files.
+func createMergeDirectories(assert *assert.Assertions, parentDirectory string) (string, string) {
+	src := filepath.Join(parentDirectory, "src")
+	dest := filepath.Join(parentDirectory, "dest")
+	for directory, files := range map[string]map[string]string{
+		src:  {"new.txt": "new", "same.txt": "same", "conflict.txt": "generated\n"},
+		dest: {"same.txt": "same", "conflict.txt": "existing\n", "other.txt": "other"},
+	} {
+		err := os.MkdirAll(directory, os.ModePerm)
+		assert.NoError(err)
+		for name, content := range files {
+			err = os.WriteFile(filepath.Join(directory, name), []byte(content), 0644)
+			assert.NoError(err)
+		}
+	}
+	return src, dest
+}
+
+// TestMergeDirectoryWithStrategies tests merging generated files into an existing directory with each conflict strategy.
+func TestMergeDirectoryWithStrategies(t *testing.T) {
+	for strategy, expectedContent := range map[string]string{
+		SKIP_CONFLICT_STRATEGY:          "existing\n",
+		OVERWRITE_CONFLICT_STRATEGY:     "generated\n",
+		BACKUP_CONFLICT_STRATEGY:        "generated\n",
+		MERGE_MARKERS_CONFLICT_STRATEGY: "<<<<<<< existing\nexisting\n=======\ngenerated\n>>>>>>> template\n",
+	} {
+		t.Run(strategy, func(t *testing.T) {
+			// Create a new testing.T instance to use with assert functions.
+			assert := assert.New(t)
+
+			src, dest := createMergeDirectories(assert, t.TempDir())
+			report, err := MergeDirectory(src, dest, strategy, nil)
+			assert.NoError(err)
+
+			// Assert that new files were created, identical files were left as they are, and other files were kept.
+			assert.Equal([]string{"new.txt"}, report.Created)
+			assert.Equal([]string{"same.txt"}, report.Unchanged)
+			content, err := os.ReadFile(filepath.Join(dest, "other.txt"))
+			assert.NoError(err)
+			assert.Equal("other", string(content))
+
+			// Assert that the conflict was resolved with the strategy.
+			content, err = os.ReadFile(filepath.Join(dest, "conflict.txt"))
+			assert.NoError(err)
+			assert.Equal(expectedContent, string(content))
+			if strategy == BACKUP_CONFLICT_STRATEGY {
+				content, err = os.ReadFile(filepath.Join(dest, "conflict.txt.bak"))
+				assert.NoError(err)
+				assert.Equal("existing\n", string(content))
+			}
+		})
+	}
+}
+
+// TestMergeDirectoryWithPromptStrategy tests that the 'prompt' strategy asks before overwriting each conflicting file.
+func TestMergeDirectoryWithPromptStrategy(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	src, dest := createMergeDirectories(assert, t.TempDir())
+	var prompted []string
+	report, err := MergeDirectory(src, dest, PROMPT_CONFLICT_STRATEGY, func(relativePath string) (bool, error) {
+		prompted = append(prompted, relativePath)
+		return true, nil
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"conflict.txt"}, prompted)
+	assert.Equal([]string{"conflict.txt"}, report.Overwritten)
+}
@@ -0,0 +1,109 @@
+package templates
+
+import (
+	"io/fs"
+	"sort"
+)
+
+// Default template action delimiters.
+const (
+	DEFAULT_LEFT_DELIMITER  = "{{"
+	DEFAULT_RIGHT_DELIMITER = "}}"
+)
+
+// Delimiters represents the action delimiters of a template, such as '{{' and '}}'.
+type Delimiters struct {
+	// Left is the left delimiter.
+	Left string
+
+	// Right is the right delimiter.
+	Right string
+}
+
+// DefaultDelimiters returns the default template action delimiters, '{{' and '}}'.
+func DefaultDelimiters() Delimiters {
+	return Delimiters{Left: DEFAULT_LEFT_DELIMITER, Right: DEFAULT_RIGHT_DELIMITER}
+}
+
+// RenderOptions holds the options that decide how each file of a template directory is rendered.
+// They are shared by 'FillDirectory' and 'LintDirectory', so both treat files the same way.
+type RenderOptions struct {
+	// CopyOnlyPaths is a list of glob patterns of files that are copied as they are, without being rendered.
+	CopyOnlyPaths []string
+
+	// RenderOnlyPaths is a list of glob patterns of files that are always rendered, even if they look binary.
+	RenderOnlyPaths []string
+
+	// Delimiters are the action delimiters of the template files. If empty, '{{' and '}}' are used.
+	Delimiters Delimiters
+
+	// DelimiterOverrides maps glob patterns to the action delimiters of the matching files.
+	// If more than one pattern matches a file, the longest pattern is used.
+	DelimiterOverrides map[string]Delimiters
+
+	// Partials is the list of partial libraries loaded before the template files.
+	// When several libraries define the same template, the last one wins, and the template files override them all.
+	Partials []PartialLibrary
+
+	// Bases is the list of filesystems of the base templates extended by the template, from the closest to the deepest.
+	// Their files are inherited, unless the template has a file with the same path, and they are parsed before the files
+	// of the template, so that the 'define' and 'block' overrides of the template take precedence.
+	Bases []fs.FS
+}
+
+// withBases returns the source filesystem layered over the filesystems of the base templates, if any.
+func (o RenderOptions) withBases(source fs.FS) fs.FS {
+	if len(o.Bases) == 0 {
+		return source
+	}
+	return NewLayeredFS(append([]fs.FS{source}, o.Bases...)...)
+}
+
+// ShouldRender decides whether a file should be rendered as a template or copied byte for byte. See 'ShouldRenderFile'.
+func (o RenderOptions) ShouldRender(relativePath string, content []byte) bool {
+	return ShouldRenderFile(relativePath, content, o.CopyOnlyPaths, o.RenderOnlyPaths)
+}
+
+// DelimitersFor returns the action delimiters of a file, given its path relative to the template directory.
+func (o RenderOptions) DelimitersFor(relativePath string) Delimiters {
+	delimiters := o.Delimiters
+	if delimiters.Left == "" || delimiters.Right == "" {
+		delimiters = DefaultDelimiters()
+	}
+
+	patterns := make([]string, 0, len(o.DelimiterOverrides))
+	for pattern := range o.DelimiterOverrides {
+		patterns = append(patterns, pattern)
+	}
+	if pattern, ok := MostSpecificPattern(relativePath, patterns); ok {
+		delimiters = o.DelimiterOverrides[pattern]
+	}
+
+	return delimiters
+}
+
+// MostSpecificPattern returns the most specific glob pattern matching a file, given its path relative to the template
+// directory, or false if no pattern matches. See 'sortPatternsBySpecificity'.
+func MostSpecificPattern(relativePath string, patterns []string) (string, bool) {
+	sortedPatterns := sortPatternsBySpecificity(append([]string(nil), patterns...))
+
+	// The last matching pattern is the most specific one.
+	for index := len(sortedPatterns) - 1; index >= 0; index-- {
+		if MatchesAnyGlob(relativePath, sortedPatterns[index:index+1]) {
+			return sortedPatterns[index], true
+		}
+	}
+	return "", false
+}
+
+// sortPatternsBySpecificity sorts glob patterns from the shortest to the longest, so that when patterns are
+// applied in order, longer and more specific patterns take precedence. Patterns of the same length are sorted alphabetically.
+func sortPatternsBySpecificity(patterns []string) []string {
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) < len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	return patterns
+}
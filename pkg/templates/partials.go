@@ -0,0 +1,69 @@
+package templates
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// PartialLibrary is a set of partial templates, such as the partials directory of a template repository or a remote library.
+// Partial files are parsed before the template files, so their 'define' blocks can be used with 'include' in every file,
+// but they are never written to the output.
+type PartialLibrary struct {
+	// Name identifies the library. It prefixes the names of its files in the template set and in error messages.
+	Name string
+
+	// FS is the filesystem holding the partial files.
+	FS fs.FS
+}
+
+// partialFile is a file read from a partial library.
+type partialFile struct {
+	// name is the name of the file in the template set: the library name followed by the path of the file in the library.
+	name string
+
+	// content is the content of the file.
+	content []byte
+}
+
+// readPartials reads the files of the partial libraries, in order.
+// Binary files, symbolic links and paths known to be irrelevant to templates, such as '.git', are skipped.
+func readPartials(libraries []PartialLibrary) ([]partialFile, error) {
+	matcher := NewIgnoreMatcher(appConfig.KnownIgnorePaths)
+
+	var files []partialFile
+	for _, library := range libraries {
+		err := fs.WalkDir(library.FS, ".", func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("error walking path %s of partials %s: %w", name, library.Name, err)
+			}
+			if name == "." {
+				return nil
+			}
+			if matcher.Match(name, entry.IsDir()) {
+				if entry.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+
+			content, err := fs.ReadFile(library.FS, name)
+			if err != nil {
+				return fmt.Errorf("error reading file %s of partials %s: %w", name, library.Name, err)
+			}
+			if IsBinaryContent(content) {
+				return nil
+			}
+			files = append(files, partialFile{name: path.Join(library.Name, name), content: content})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
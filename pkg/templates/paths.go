@@ -0,0 +1,93 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnsureInsideDirectory checks that a path stays inside a base directory, and returns an error otherwise.
+// Every path written to disk while generating files, such as the ones written by 'DiskFS' or copied by 'CopyDirectory',
+// must go through this check.
+//
+// Both paths are made absolute and cleaned, so '..' segments are resolved, and the comparison is done
+// by path segments, so '/tmp/repo-evil' is not considered inside '/tmp/repo'. Symbolic links in the existing
+// part of the path are also resolved, so a link pointing outside the base directory cannot be used to escape it.
+func EnsureInsideDirectory(baseDirectory, path string) error {
+	absBaseDirectory, err := filepath.Abs(baseDirectory)
+	if err != nil {
+		return fmt.Errorf("error getting absolute path of %s: %w", baseDirectory, err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("error getting absolute path of %s: %w", path, err)
+	}
+
+	// Check the path as it is written.
+	if !isInside(absBaseDirectory, absPath) {
+		return fmt.Errorf("path %s is outside the directory %s", path, baseDirectory)
+	}
+
+	// Check the path with its symbolic links resolved.
+	resolvedBaseDirectory, err := resolveExistingPath(absBaseDirectory)
+	if err != nil {
+		return err
+	}
+	resolvedPath, err := resolveExistingPath(absPath)
+	if err != nil {
+		return err
+	}
+	if !isInside(resolvedBaseDirectory, resolvedPath) {
+		return fmt.Errorf("path %s is outside the directory %s through a symbolic link", path, baseDirectory)
+	}
+
+	return nil
+}
+
+// isInside returns true if 'path' is 'baseDirectory' or is inside it. Both paths must be absolute and clean.
+func isInside(baseDirectory, path string) bool {
+	relativePath, err := filepath.Rel(baseDirectory, path)
+	if err != nil {
+		return false
+	}
+	return relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(os.PathSeparator)) && !filepath.IsAbs(relativePath)
+}
+
+// resolveExistingPath resolves the symbolic links of the longest existing part of an absolute path,
+// and appends the remaining part, which does not exist yet, as it is.
+func resolveExistingPath(path string) (string, error) {
+	existingPath := path
+	var missingPath string
+	for {
+		resolvedPath, err := filepath.EvalSymlinks(existingPath)
+		if err == nil {
+			return filepath.Join(resolvedPath, missingPath), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("error resolving symbolic links of %s: %w", path, err)
+		}
+
+		// A dangling symbolic link exists even though its target does not, and writing to it would create the target.
+		if IsSymlink(existingPath) {
+			target, err := os.Readlink(existingPath)
+			if err != nil {
+				return "", fmt.Errorf("error reading symbolic link %s: %w", existingPath, err)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(existingPath), target)
+			}
+			return resolveExistingPath(filepath.Join(target, missingPath))
+		}
+
+		// Move one level up, keeping the missing part to append it later.
+		parentPath := filepath.Dir(existingPath)
+		if parentPath == existingPath {
+			return path, nil
+		}
+		missingPath = filepath.Join(filepath.Base(existingPath), missingPath)
+		existingPath = parentPath
+	}
+}
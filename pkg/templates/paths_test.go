@@ -0,0 +1,92 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEnsureInsideDirectoryWithPathsInside tests that paths inside the base directory are accepted,
+// including paths that do not exist yet and paths with '..' segments that stay inside.
+func TestEnsureInsideDirectoryWithPathsInside(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	baseDirectory := filepath.Join(t.TempDir(), "repo")
+	err := os.MkdirAll(filepath.Join(baseDirectory, "sub"), os.ModePerm)
+	assert.NoError(err)
+
+	assert.NoError(EnsureInsideDirectory(baseDirectory, baseDirectory))
+	assert.NoError(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "file.txt")))
+	assert.NoError(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "new", "dir", "file.txt")))
+	assert.NoError(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "sub", "..", "file.txt")))
+}
+
+// TestEnsureInsideDirectoryWithPrefixSibling tests that a sibling directory sharing a prefix with the base directory is rejected.
+func TestEnsureInsideDirectoryWithPrefixSibling(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	parentDirectory := t.TempDir()
+	baseDirectory := filepath.Join(parentDirectory, "myrepo")
+	err := os.MkdirAll(baseDirectory, os.ModePerm)
+	assert.NoError(err)
+
+	err = EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "..", "myrepo-evil", "x"))
+	assert.Error(err)
+	err = EnsureInsideDirectory(baseDirectory, filepath.Join(parentDirectory, "myrepo-evil", "x"))
+	assert.Error(err)
+}
+
+// TestEnsureInsideDirectoryWithParentSegments tests that paths leaving the base directory with '..' segments are rejected.
+func TestEnsureInsideDirectoryWithParentSegments(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	baseDirectory := filepath.Join(t.TempDir(), "repo")
+	err := os.MkdirAll(baseDirectory, os.ModePerm)
+	assert.NoError(err)
+
+	assert.Error(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "..")))
+	assert.Error(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "..", "file.txt")))
+	assert.Error(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "sub", "..", "..", "file.txt")))
+}
+
+// TestEnsureInsideDirectoryWithSymlinkEscape tests that paths escaping the base directory through
+// a symbolic link, including a dangling one, are rejected.
+func TestEnsureInsideDirectoryWithSymlinkEscape(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not fully supported on Windows")
+	}
+
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	parentDirectory := t.TempDir()
+	baseDirectory := filepath.Join(parentDirectory, "repo")
+	outsideDirectory := filepath.Join(parentDirectory, "outside")
+	err := os.MkdirAll(baseDirectory, os.ModePerm)
+	assert.NoError(err)
+	err = os.MkdirAll(outsideDirectory, os.ModePerm)
+	assert.NoError(err)
+
+	// A directory link pointing outside the base directory.
+	err = os.Symlink(outsideDirectory, filepath.Join(baseDirectory, "link"))
+	assert.NoError(err)
+	assert.Error(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "link", "file.txt")))
+
+	// A dangling file link pointing outside the base directory.
+	err = os.Symlink(filepath.Join(outsideDirectory, "missing.txt"), filepath.Join(baseDirectory, "dangling.txt"))
+	assert.NoError(err)
+	assert.Error(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "dangling.txt")))
+
+	// A link pointing inside the base directory is accepted.
+	err = os.MkdirAll(filepath.Join(baseDirectory, "real"), os.ModePerm)
+	assert.NoError(err)
+	err = os.Symlink("real", filepath.Join(baseDirectory, "inside"))
+	assert.NoError(err)
+	assert.NoError(EnsureInsideDirectory(baseDirectory, filepath.Join(baseDirectory, "inside", "file.txt")))
+}
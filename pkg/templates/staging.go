@@ -0,0 +1,119 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/config"
+)
+
+// IsDirectoryEmpty returns true if a directory is empty or does not exist.
+func IsDirectoryEmpty(path string) (bool, error) {
+	directory, err := os.Open(path)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer directory.Close()
+
+	_, err = directory.Readdirnames(1)
+	if err == io.EOF {
+		return true, nil
+	}
+	return false, err
+}
+
+// StagingDirectory is a directory where files are generated before being moved into their final place,
+// so that a failure never leaves a half-generated directory behind nor touches pre-existing content.
+type StagingDirectory struct {
+	// Path is the directory where the files should be generated.
+	Path string
+
+	// TargetPath is the final directory, where the files are moved to by 'Commit'.
+	TargetPath string
+
+	// container is the directory that holds the staging directory. It is a hidden directory next to the target directory,
+	// so that, being on the same filesystem, the staging directory can be renamed atomically, except for merges.
+	container string
+}
+
+// NewStagingDirectory creates an empty staging directory next to the target directory.
+// The parent directories of the target directory are created if needed.
+func NewStagingDirectory(targetPath string) (*StagingDirectory, error) {
+	parentPath := filepath.Dir(targetPath)
+	if err := os.MkdirAll(parentPath, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating directory %s: %w", parentPath, err)
+	}
+
+	container, err := os.MkdirTemp(parentPath, fmt.Sprintf(".%s.cloney-staging-*", filepath.Base(targetPath)))
+	if err != nil {
+		return nil, fmt.Errorf("error creating staging directory next to %s: %w", targetPath, err)
+	}
+	return newStagingDirectory(container, targetPath)
+}
+
+// NewMergeStagingDirectory creates an empty staging directory for files merged into an existing target directory
+// with 'MergeDirectory'. Merging never renames the staging directory, so it is created in the cache directory,
+// or in the default directory for temporary files, instead of next to the target directory, which may not be writable.
+// It must not be committed.
+func NewMergeStagingDirectory(targetPath string) (*StagingDirectory, error) {
+	container, err := config.CreateTemporaryDirectory("cloney-staging-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating staging directory for %s: %w", targetPath, err)
+	}
+	return newStagingDirectory(container, targetPath)
+}
+
+// newStagingDirectory creates the staging directory of a target directory inside its container.
+func newStagingDirectory(container, targetPath string) (*StagingDirectory, error) {
+	// The staging directory is created inside the container with the default permissions, respecting the user's umask.
+	stagingPath := filepath.Join(container, filepath.Base(targetPath))
+	if err := os.Mkdir(stagingPath, os.ModePerm); err != nil {
+		os.RemoveAll(container)
+		return nil, fmt.Errorf("error creating staging directory %s: %w", stagingPath, err)
+	}
+
+	return &StagingDirectory{
+		Path:       stagingPath,
+		TargetPath: targetPath,
+		container:  container,
+	}, nil
+}
+
+// Commit moves the staging directory to the target directory.
+// If the target directory exists, it is moved aside first and only deleted by 'Cleanup',
+// and it is restored if the staging directory cannot be moved into place.
+func (s *StagingDirectory) Commit() error {
+	if _, err := os.Lstat(s.TargetPath); err == nil {
+		// The previous directory is moved into a new directory, so its name cannot collide with the staging directory.
+		previousContainer, err := os.MkdirTemp(s.container, "previous-*")
+		if err != nil {
+			return fmt.Errorf("error moving existing directory %s aside: %w", s.TargetPath, err)
+		}
+		previousPath := filepath.Join(previousContainer, filepath.Base(s.TargetPath))
+		if err := os.Rename(s.TargetPath, previousPath); err != nil {
+			return fmt.Errorf("error moving existing directory %s aside: %w", s.TargetPath, err)
+		}
+		if err := os.Rename(s.Path, s.TargetPath); err != nil {
+			os.Rename(previousPath, s.TargetPath)
+			return fmt.Errorf("error moving staging directory to %s: %w", s.TargetPath, err)
+		}
+		return nil
+	}
+
+	if err := os.Rename(s.Path, s.TargetPath); err != nil {
+		return fmt.Errorf("error moving staging directory to %s: %w", s.TargetPath, err)
+	}
+	return nil
+}
+
+// Cleanup deletes the staging directory and, after a successful 'Commit', the previous target directory.
+// It is safe to call it more than once, and it never touches the target directory.
+func (s *StagingDirectory) Cleanup() {
+	os.RemoveAll(s.container)
+}
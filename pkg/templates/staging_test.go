@@ -0,0 +1,132 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStagingDirectoryCommit tests that a staging directory is moved into place, replacing the existing target directory,
+// and that nothing is left next to the target directory after the cleanup.
+func TestStagingDirectoryCommit(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	parentDirectory := t.TempDir()
+	targetPath := filepath.Join(parentDirectory, "project")
+	err := os.MkdirAll(targetPath, os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join(targetPath, "old.txt"), []byte("old"), os.ModePerm)
+	assert.NoError(err)
+
+	staging, err := NewStagingDirectory(targetPath)
+	assert.NoError(err)
+	defer staging.Cleanup()
+	err = os.WriteFile(filepath.Join(staging.Path, "new.txt"), []byte("new"), os.ModePerm)
+	assert.NoError(err)
+
+	err = staging.Commit()
+	assert.NoError(err)
+	staging.Cleanup()
+
+	// Assert that the target directory was replaced and that the staging directory was deleted.
+	_, err = os.Stat(filepath.Join(targetPath, "new.txt"))
+	assert.NoError(err)
+	_, err = os.Stat(filepath.Join(targetPath, "old.txt"))
+	assert.Error(err)
+	entries, err := os.ReadDir(parentDirectory)
+	assert.NoError(err)
+	assert.Len(entries, 1)
+}
+
+// TestStagingDirectoryCleanupWithoutCommit tests that cleaning up a staging directory that was not committed,
+// as when generation fails, does not touch the pre-existing target directory.
+func TestStagingDirectoryCleanupWithoutCommit(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	parentDirectory := t.TempDir()
+	targetPath := filepath.Join(parentDirectory, "project")
+	err := os.MkdirAll(targetPath, os.ModePerm)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join(targetPath, "existing.txt"), []byte("existing"), os.ModePerm)
+	assert.NoError(err)
+
+	staging, err := NewStagingDirectory(targetPath)
+	assert.NoError(err)
+	err = os.WriteFile(filepath.Join(staging.Path, "partial.txt"), []byte("partial"), os.ModePerm)
+	assert.NoError(err)
+	staging.Cleanup()
+
+	// Assert that the target directory is untouched and that the staging directory was deleted.
+	content, err := os.ReadFile(filepath.Join(targetPath, "existing.txt"))
+	assert.NoError(err)
+	assert.Equal("existing", string(content))
+	entries, err := os.ReadDir(parentDirectory)
+	assert.NoError(err)
+	assert.Len(entries, 1)
+}
+
+// TestStagingDirectoryCommitWithTargetNamedPrevious tests committing a staging directory to an existing target
+// directory named 'previous', whose name must not collide with the directory where the previous target is moved aside.
+func TestStagingDirectoryCommitWithTargetNamedPrevious(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	for _, stagedFiles := range [][]string{{"new.txt"}, {}} {
+		parentDirectory := t.TempDir()
+		targetPath := filepath.Join(parentDirectory, "previous")
+		err := os.MkdirAll(targetPath, os.ModePerm)
+		assert.NoError(err)
+		err = os.WriteFile(filepath.Join(targetPath, "old.txt"), []byte("old"), os.ModePerm)
+		assert.NoError(err)
+
+		staging, err := NewStagingDirectory(targetPath)
+		assert.NoError(err)
+		for _, name := range stagedFiles {
+			err = os.WriteFile(filepath.Join(staging.Path, name), []byte("new"), os.ModePerm)
+			assert.NoError(err)
+		}
+
+		err = staging.Commit()
+		assert.NoError(err)
+		staging.Cleanup()
+
+		// Assert that the target directory was replaced by the staged files, even if there are none.
+		entries, err := os.ReadDir(targetPath)
+		assert.NoError(err)
+		assert.Len(entries, len(stagedFiles))
+		for _, name := range stagedFiles {
+			assert.FileExists(filepath.Join(targetPath, name))
+		}
+		entries, err = os.ReadDir(parentDirectory)
+		assert.NoError(err)
+		assert.Len(entries, 1)
+	}
+}
+
+// TestMergeStagingDirectoryIsNotNextToTarget tests that the staging directory of a merge is not created next to
+// the target directory, whose parent directory may not be writable.
+func TestMergeStagingDirectoryIsNotNextToTarget(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	parentDirectory := t.TempDir()
+	targetPath := filepath.Join(parentDirectory, "project")
+	err := os.MkdirAll(targetPath, os.ModePerm)
+	assert.NoError(err)
+
+	staging, err := NewMergeStagingDirectory(targetPath)
+	assert.NoError(err)
+	assert.DirExists(staging.Path)
+	assert.Equal(targetPath, staging.TargetPath)
+
+	// Assert that nothing was created next to the target directory, and that the staging directory is deleted.
+	entries, err := os.ReadDir(parentDirectory)
+	assert.NoError(err)
+	assert.Len(entries, 1)
+	staging.Cleanup()
+	assert.NoDirExists(staging.Path)
+}
@@ -2,11 +2,17 @@ package templates
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/ArthurSudbrackIbarra/cloney/pkg/config"
@@ -21,6 +27,18 @@ var appConfig = config.GetAppConfig()
 type TemplateFiller struct {
 	// Variables contains the variables to be injected into the template.
 	Variables map[string]interface{}
+
+	// RenderOptions decide which files are rendered and with which action delimiters.
+	RenderOptions
+
+	// FileModes maps glob patterns to the permissions of the matching files, overriding the modes of the template files.
+	FileModes map[string]os.FileMode
+
+	// Workers is the maximum number of files read and executed concurrently. If zero, one worker per CPU is used.
+	Workers int
+
+	// Silent disables the debug messages and the progress line, for programs that embed Cloney.
+	Silent bool
 }
 
 // NewTemplateFiller creates a new TemplateFiller instance initialized with the provided variables.
@@ -30,106 +48,391 @@ func NewTemplateFiller(variablesMap map[string]interface{}) *TemplateFiller {
 	}
 }
 
-// injectCustomToFileFuncPaths takes a file path, its content, and a flag indicating whether the output
-// is intended for the terminal. It returns a modified version of the file content with the two first hidden
-// parameter of the 'toFile' functions injected.
-//
-// If 'outputInTerminal' is true, an error is returned because the 'toFile' function is not supported in
-// terminal output mode; it should be used with 'cloney dry-run -o <output_directory>' instead.
-func injectCustomToFileFuncPaths(templateDir, filePath, fileContent string, outputInTerminal bool) (string, error) {
-	// Split the template content into lines for processing.
-	fileLines := strings.Split(fileContent, "\n")
+// debugMessage prints a debug message, unless the filler is silent.
+func (t *TemplateFiller) debugMessage(message string) {
+	if !t.Silent {
+		terminal.DebugMessage(message)
+	}
+}
 
-	// Iterate over each line in the template content.
-	for index, line := range fileLines {
-		// Inject the "hidden" parameters.
-		regex := regexp.MustCompile(`{{-? ?toFile`)
-		fileDir := filepath.Dir(filePath)
+// sourceFile holds a file read from the source filesystem while rendering.
+type sourceFile struct {
+	// name is the slash-separated path of the file, relative to the root of the filesystem.
+	name string
 
-		// If on Windows, replace backslashes with forward slashes.
-		if os.PathSeparator == '\\' {
-			templateDir = filepath.ToSlash(templateDir)
-			fileDir = filepath.ToSlash(fileDir)
-		}
-		newLine := regex.ReplaceAllString(line, fmt.Sprintf("{{- toFile \"%s\" \"%s\"", templateDir, fileDir))
+	// content is the content of the file.
+	content []byte
 
-		// If 'outputInTerminal' is true, return an error as "toFile" is not supported in terminal output mode.
-		// if outputInTerminal {
-		if newLine != line && outputInTerminal {
-			return "", fmt.Errorf("the 'toFile' function is not supported when outputting the result to the terminal. Use 'cloney dry-run -o <output_directory>' instead")
-		}
+	// mode is the file mode of the file.
+	mode fs.FileMode
 
-		// Replace the line in the file content.
-		fileLines[index] = newLine
-	}
+	// rendered is true if the file is rendered as a template, and false if it is copied as it is.
+	rendered bool
 
-	// Reconstruct the modified file content.
-	newContent := strings.Join(fileLines, "\n")
-	return newContent, nil
+	// layer is 0 for the files of the template, and the depth of the base template for inherited files.
+	layer int
 }
 
-// FillDirectory processes template files in a source directory, replacing placeholders with variables.
-func (t *TemplateFiller) FillDirectory(src string, ignorePaths []string, outputInTerminal bool) error {
-	// Get a list of all files in the specified directory, considering ignore options.
-	filePaths, err := GetAllFilePaths(src, ignorePaths)
-	if err != nil {
-		return fmt.Errorf("error obtaining file paths in directory %s: %w", src, err)
-	}
+// fileWrite is a file to be written to the output filesystem once all files were rendered.
+type fileWrite struct {
+	// name is the slash-separated path of the file, relative to the root of the output filesystem.
+	name string
+
+	// content is the content of the file.
+	content []byte
+
+	// perm is the permissions of the file.
+	perm fs.FileMode
+}
+
+// Render renders the template files of the 'source' filesystem into the 'output' filesystem.
+//
+// Files matching 'ignorePaths' are skipped. Template files are rendered with the variables, while binary and
+// copy-only files are copied byte for byte, and symbolic links are recreated, as long as they stay inside the template.
+// File modes are preserved, unless they are overridden by 'FileModes'. Files created with 'toFile' are also written to 'output'.
+// The source and output filesystems can be the same, since all files are read before anything is written.
+//
+// Files are read and executed concurrently by up to 'Workers' goroutines, but they are all parsed into a single
+// template set in between, after the partials and the files inherited from the base templates, so that they can include
+// each other. Nothing is written if any file fails, and the errors of all files are returned together.
+// Files are written in the order of their names, so the result does not depend on scheduling.
+// Rendering stops as soon as the context is cancelled, between files, and the error of the context is returned.
+// Nothing is written in that case either.
+func (t *TemplateFiller) Render(ctx context.Context, source fs.FS, ignorePaths []string, output WritableFS) error {
+	matcher := NewIgnoreMatcher(ignorePaths)
+
+	// Files of the base templates are inherited, unless the template has a file with the same path.
+	source = t.withBases(source)
+	layered, _ := source.(*LayeredFS)
 
 	// Create a template and add custom functions.
 	tmpl := template.New("")
 	tmpl.Funcs(sprig.TxtFuncMap())
 	tmpl.Funcs(CustomTxtFuncMap(tmpl))
 
-	// Create a map to hold the file contents.
-	fileContents := make(map[string]string)
+	// List all files, considering ignore options.
+	var files []sourceFile
+	symlinkTargets := make(map[string]string)
+	var symlinkNames []string
+	err := fs.WalkDir(source, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("error walking path %s: %w", name, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+
+		// Check if the path should be ignored.
+		if matcher.Match(name, entry.IsDir()) {
+			if entry.IsDir() {
+				t.debugMessage(fmt.Sprintf("Ignored '%s/': it matches an ignore pattern", name))
+				return fs.SkipDir
+			}
+			t.debugMessage(fmt.Sprintf("Ignored '%s': it matches an ignore pattern", name))
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		// Symbolic links are not followed, and their targets must stay inside the template directory.
+		if entry.Type()&fs.ModeSymlink != 0 {
+			linkFS, ok := source.(ReadLinkFS)
+			if !ok {
+				return fmt.Errorf("cannot read symbolic link %s: the filesystem does not support symbolic links", name)
+			}
+			target, err := linkFS.ReadLink(name)
+			if err != nil {
+				return fmt.Errorf("error reading symbolic link %s: %w", name, err)
+			}
+			if _, err := resolveLinkTarget(name, target); err != nil {
+				return err
+			}
+			symlinkTargets[name] = target
+			symlinkNames = append(symlinkNames, name)
+			return nil
+		}
 
-	// Iterate over each file in the directory and read the content.
-	for _, filePath := range filePaths {
-		// Read the content of the file.
-		fileBytes, err := os.ReadFile(filePath)
+		info, err := entry.Info()
 		if err != nil {
-			return fmt.Errorf("error reading file %s: %w", filePath, err)
+			return fmt.Errorf("error reading file information of %s: %w", name, err)
 		}
+		file := sourceFile{name: name, mode: info.Mode()}
+		if layered != nil {
+			file.layer = layered.LayerOf(name)
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		// Get a new version of the file content with the first hidden parameter of the 'toFile' function injected.
-		fileContent, err := injectCustomToFileFuncPaths(src, filePath, string(fileBytes), outputInTerminal)
+	// Read all files concurrently.
+	readErrors := make([]error, len(files))
+	t.forEachFile(ctx, len(files), func(index int) {
+		file := &files[index]
+		content, err := fs.ReadFile(source, file.name)
 		if err != nil {
-			return err
+			readErrors[index] = fmt.Errorf("error reading file %s: %w", file.name, err)
+			return
 		}
 
-		fileContents[filePath] = fileContent
+		// Binary and copy-only files are not rendered, so they are kept byte for byte.
+		file.content = content
+		file.rendered = t.ShouldRender(file.name, content)
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := errors.Join(readErrors...); err != nil {
+		return err
 	}
 
-	// Parse all file contents into the template.
-	for filePath, fileContent := range fileContents {
-		_, err = tmpl.New(filePath).Parse(fileContent)
+	// Parse the partials first, so that the template files can include them and override their 'define' blocks.
+	partials, err := readPartials(t.Partials)
+	if err != nil {
+		return err
+	}
+	var parseErrors []error
+	for _, partial := range partials {
+		delimiters := t.DelimitersFor(partial.name)
+		_, err = tmpl.New(partial.name).Delims(delimiters.Left, delimiters.Right).Parse(string(partial.content))
 		if err != nil {
-			return fmt.Errorf("error parsing template for file %s: %w", filePath, err)
+			parseErrors = append(parseErrors, fmt.Errorf("error parsing partial template %s: %w", partial.name, err))
 		}
 	}
 
-	// Execute the templates for each file.
-	for _, filePath := range filePaths {
-		var resultBuffer bytes.Buffer
-		err = tmpl.ExecuteTemplate(&resultBuffer, filePath, t.Variables)
+	// Parse all template files, each one with its own delimiters, starting with the files of the deepest base template.
+	// Since all files share the same template set, they can include each other regardless of their delimiters.
+	parseOrder := make([]sourceFile, len(files))
+	copy(parseOrder, files)
+	sort.SliceStable(parseOrder, func(i, j int) bool {
+		return parseOrder[i].layer > parseOrder[j].layer
+	})
+	for _, file := range parseOrder {
+		if !file.rendered {
+			continue
+		}
+		delimiters := t.DelimitersFor(file.name)
+		_, err = tmpl.New(file.name).Delims(delimiters.Left, delimiters.Right).Parse(string(file.content))
 		if err != nil {
-			return fmt.Errorf("error executing template for file %s: %w", filePath, err)
+			parseErrors = append(parseErrors, fmt.Errorf("error parsing template for file %s: %w", file.name, err))
 		}
+	}
+	if err := errors.Join(parseErrors...); err != nil {
+		return err
+	}
+
+	// Execute the templates concurrently. Each file results in a list of writes: the files
+	// created with 'toFile', in the order they were created, followed by the file itself.
+	progress := new(terminal.Progress)
+	if !t.Silent {
+		progress = terminal.NewProgress("Rendering files", len(files))
+	}
+	defer progress.Done()
+	writes := make([][]fileWrite, len(files))
+	executeErrors := make([]error, len(files))
+	workerTemplates := make(chan *template.Template, t.workers())
+	t.forEachFile(ctx, len(files), func(index int) {
+		file := files[index]
+		defer progress.Increment()
 
-		// If the 'outputInTerminal' parameter is set, output the result to the terminal.
-		if outputInTerminal {
-			if !strings.HasPrefix(filepath.Base(filePath), appConfig.IgnorePrefix) {
-				terminal.Message(fmt.Sprintf("\n--- File (%s)\n%s\n", terminal.Blue(filePath), resultBuffer.String()))
+		// Files that are not rendered are copied as they are.
+		if !file.rendered {
+			t.debugMessage(fmt.Sprintf("Copied '%s': it is a binary or copy-only file", file.name))
+			writes[index] = []fileWrite{{name: file.name, content: file.content, perm: file.mode.Perm()}}
+			return
+		}
+
+		// Each worker executes files with its own clone of the template set, so that the
+		// 'toFile' function can be bound to the directory of the file being executed.
+		var fileTmpl *template.Template
+		select {
+		case fileTmpl = <-workerTemplates:
+		default:
+			var err error
+			fileTmpl, err = tmpl.Clone()
+			if err != nil {
+				executeErrors[index] = fmt.Errorf("error preparing template for file %s: %w", file.name, err)
+				return
 			}
-		} else {
-			// Write the result to the same file.
-			err = os.WriteFile(filePath, resultBuffer.Bytes(), os.ModePerm)
+			fileTmpl.Funcs(CustomTxtFuncMap(fileTmpl))
+		}
+		defer func() { workerTemplates <- fileTmpl }()
+
+		var fileWrites []fileWrite
+		fileTmpl.Funcs(template.FuncMap{
+			"toFile": toFileFunc(fileTmpl, path.Dir(file.name), func(name string, content []byte) error {
+				fileWrites = append(fileWrites, fileWrite{name: name, content: content, perm: 0666})
+				return nil
+			}),
+		})
+
+		var resultBuffer bytes.Buffer
+		err := fileTmpl.ExecuteTemplate(&resultBuffer, file.name, t.Variables)
+		if err != nil {
+			executeErrors[index] = fmt.Errorf("error executing template for file %s: %w", file.name, err)
+			return
+		}
+		for _, write := range fileWrites {
+			t.debugMessage(fmt.Sprintf("Rendered '%s': it was created with 'toFile' by '%s'", write.name, file.name))
+		}
+		t.debugMessage(fmt.Sprintf("Rendered '%s'", file.name))
+		writes[index] = append(fileWrites, fileWrite{name: file.name, content: resultBuffer.Bytes(), perm: file.mode.Perm()})
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := errors.Join(executeErrors...); err != nil {
+		return err
+	}
+
+	// Write the files in order.
+	for _, fileWrites := range writes {
+		for _, write := range fileWrites {
+			err = t.writeFile(output, write.name, write.content, write.perm)
 			if err != nil {
-				return fmt.Errorf("error writing file %s: %w", filePath, err)
+				return err
 			}
 		}
 	}
 
+	// Recreate the symbolic links.
+	for _, name := range symlinkNames {
+		t.debugMessage(fmt.Sprintf("Linked '%s' to '%s'", name, symlinkTargets[name]))
+		err = output.Symlink(symlinkTargets[name], name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// workers returns the maximum number of files processed concurrently.
+func (t *TemplateFiller) workers() int {
+	if t.Workers > 0 {
+		return t.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// forEachFile calls 'process' for every index from 0 to 'count' - 1, using up to 'workers' goroutines at the same time.
+// No more calls are started once the context is cancelled. It returns once all started calls returned.
+func (t *TemplateFiller) forEachFile(ctx context.Context, count int, process func(index int)) {
+	indexes := make(chan int)
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < min(t.workers(), count); worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range indexes {
+				process(index)
+			}
+		}()
+	}
+	for index := 0; index < count && ctx.Err() == nil; index++ {
+		indexes <- index
+	}
+	close(indexes)
+	waitGroup.Wait()
+}
+
+// writeFile writes a file to the output filesystem, applying the file mode overrides.
+func (t *TemplateFiller) writeFile(output WritableFS, name string, content []byte, perm fs.FileMode) error {
+	err := output.WriteFile(name, content, perm)
+	if err != nil {
+		return fmt.Errorf("error writing file %s: %w", name, err)
+	}
+
+	// Apply the file mode override of the most specific matching pattern, if any.
+	patterns := make([]string, 0, len(t.FileModes))
+	for pattern := range t.FileModes {
+		patterns = append(patterns, pattern)
+	}
+	if pattern, ok := MostSpecificPattern(name, patterns); ok {
+		err = output.Chmod(name, t.FileModes[pattern])
+		if err != nil {
+			return fmt.Errorf("error changing the mode of file %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// FillDirectory processes template files in a source directory, replacing placeholders with variables.
+// The files are rendered in memory first, so nothing is written if rendering fails.
+//
+// If 'outputInTerminal' is true, the result is printed to the terminal, including the files created with 'toFile'.
+// Otherwise, the files are overwritten in place.
+func (t *TemplateFiller) FillDirectory(ctx context.Context, src string, ignorePaths []string, outputInTerminal bool) error {
+	sourceFS := NewDiskFS(src)
+	memoryFS := NewMemoryFS()
+	err := t.Render(ctx, sourceFS, ignorePaths, memoryFS)
+	if err != nil {
+		return err
+	}
+
+	if outputInTerminal {
+		return t.PrintFiles(src, t.withBases(sourceFS), memoryFS)
+	}
+	return memoryFS.CommitTo(sourceFS)
+}
+
+// RenderDirectory renders the template files of a source directory into a destination directory.
+// The files are rendered in memory first and written to a staging directory, and the destination directory is only
+// replaced once every file was written, so a failure keeps the previous content of the destination directory.
+func (t *TemplateFiller) RenderDirectory(ctx context.Context, src, dest string, ignorePaths []string) error {
+	memoryFS := NewMemoryFS()
+	err := t.Render(ctx, NewDiskFS(src), ignorePaths, memoryFS)
+	if err != nil {
+		return err
+	}
+
+	// Write the files next to the destination directory, and swap it in.
+	staging, err := NewStagingDirectory(dest)
+	if err != nil {
+		return err
+	}
+	defer staging.Cleanup()
+	err = memoryFS.CommitTo(NewDiskFS(staging.Path))
+	if err != nil {
+		return err
+	}
+	return staging.Commit()
+}
+
+// PrintFiles prints the rendered files to the terminal. 'displayRoot' is prepended to the file names.
+// Files that do not exist in the source filesystem were created with 'toFile', and files starting
+// with the Ignore Prefix are not printed.
+func (t *TemplateFiller) PrintFiles(displayRoot string, source fs.FS, rendered *MemoryFS) error {
+	for _, name := range rendered.Names() {
+		if strings.HasPrefix(path.Base(name), appConfig.IgnorePrefix) {
+			continue
+		}
+		displayPath := filepath.Join(displayRoot, filepath.FromSlash(name))
+
+		if rendered.IsSymlink(name) {
+			target, _ := rendered.ReadLink(name)
+			terminal.Message(fmt.Sprintf("\n--- File (%s)\n[Symbolic link to %s]\n", terminal.Blue(displayPath), target))
+			continue
+		}
+
+		content, err := rendered.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fs.Stat(source, name); err != nil {
+			terminal.Message(fmt.Sprintf("\n--- File (%s) [created with 'toFile']\n%s\n", terminal.Blue(displayPath), content))
+		} else if !t.ShouldRender(name, content) {
+			terminal.Message(fmt.Sprintf("\n--- File (%s)\n[Not rendered, copied as is: %d bytes]\n", terminal.Blue(displayPath), len(content)))
+		} else {
+			terminal.Message(fmt.Sprintf("\n--- File (%s)\n%s\n", terminal.Blue(displayPath), content))
+		}
+	}
 	return nil
 }
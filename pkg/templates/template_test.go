@@ -0,0 +1,142 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ArthurSudbrackIbarra/cloney/pkg/terminal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderIsDeterministicWithWorkers tests that rendering many files concurrently produces the same result
+// as rendering them one by one, including files that include each other and files created with 'toFile'.
+// Every file writes its own content to the same file with 'toFile', and the last file in name order must win.
+func TestRenderIsDeterministicWithWorkers(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	source := fstest.MapFS{
+		"_defs.txt": {Data: []byte("{{ define \"header\" }}# {{ .name }}{{ end }}{{ define \"index\" }}written by {{ . }}{{ end }}")},
+	}
+	for index := 0; index < 200; index++ {
+		source[fmt.Sprintf("files/%03d.txt", index)] = &fstest.MapFile{
+			Data: []byte(fmt.Sprintf("{{ include \"header\" . }} %d{{ toFile \"../shared.txt\" \"index\" %d }}{{ toFile \"%d.gen\" \"header\" . }}", index, index, index)),
+		}
+	}
+
+	render := func(workers int) *MemoryFS {
+		filler := NewTemplateFiller(map[string]interface{}{"name": "cloney"})
+		filler.Workers = workers
+		output := NewMemoryFS()
+		err := filler.Render(context.Background(), source, nil, output)
+		assert.NoError(err)
+		return output
+	}
+	expected := render(1)
+	actual := render(8)
+
+	// Assert that both outputs have the same files with the same contents.
+	assert.Equal(expected.Names(), actual.Names())
+	for _, name := range expected.Names() {
+		expectedContent, _ := expected.ReadFile(name)
+		actualContent, _ := actual.ReadFile(name)
+		assert.Equal(string(expectedContent), string(actualContent), name)
+	}
+	content, err := actual.ReadFile("files/042.txt")
+	assert.NoError(err)
+	assert.Equal("# cloney 42", string(content))
+	content, err = actual.ReadFile("files/42.gen")
+	assert.NoError(err)
+	assert.Equal("# cloney", string(content))
+	content, err = actual.ReadFile("shared.txt")
+	assert.NoError(err)
+	assert.Equal("written by 199", string(content))
+}
+
+// TestRenderAggregatesErrors tests that the errors of all files are returned together, in the order of the file names,
+// and that nothing is written to the output filesystem.
+func TestRenderAggregatesErrors(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	source := fstest.MapFS{
+		"a.txt": {Data: []byte("ok")},
+		"b.txt": {Data: []byte("{{ fail \"first\" }}")},
+		"c.txt": {Data: []byte("{{ fail \"second\" }}")},
+	}
+
+	filler := NewTemplateFiller(nil)
+	filler.Workers = 4
+	output := NewMemoryFS()
+	err := filler.Render(context.Background(), source, nil, output)
+	assert.Error(err)
+	assert.Regexp("(?s)b.txt.*first.*c.txt.*second", err.Error())
+	assert.Empty(output.Names())
+}
+
+// TestRenderStopsWhenContextIsCancelled tests that rendering stops with the error of the context once it is cancelled,
+// and that nothing is written to the output filesystem.
+func TestRenderStopsWhenContextIsCancelled(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	source := fstest.MapFS{}
+	for index := 0; index < 50; index++ {
+		source[fmt.Sprintf("files/%02d.txt", index)] = &fstest.MapFile{Data: []byte("{{ .name }}")}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	filler := NewTemplateFiller(map[string]interface{}{"name": "cloney"})
+	output := NewMemoryFS()
+	err := filler.Render(ctx, source, nil, output)
+	assert.ErrorIs(err, context.Canceled)
+	assert.Empty(output.Names())
+}
+
+// TestRenderPrintsDebugMessages tests rendering files concurrently in the debug log level.
+// It should print what happened to each file, and nothing in the normal log level.
+func TestRenderPrintsDebugMessages(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	var buffer bytes.Buffer
+	terminal.SetCmd(&cobra.Command{})
+	terminal.SetTestMode(&buffer)
+	terminal.SetLogLevel(terminal.DEBUG_LOG_LEVEL)
+	defer func() {
+		terminal.SetCmd(nil)
+		terminal.SetTestMode(nil)
+		terminal.SetLogLevel(terminal.NORMAL_LOG_LEVEL)
+	}()
+
+	source := fstest.MapFS{
+		"ignored/file.txt": &fstest.MapFile{Data: []byte("ignored")},
+		"image.png":        &fstest.MapFile{Data: []byte{0x89, 0x00, 0x01}},
+	}
+	for index := 0; index < 20; index++ {
+		source[fmt.Sprintf("files/%02d.txt", index)] = &fstest.MapFile{Data: []byte("{{ .name }}")}
+	}
+
+	filler := NewTemplateFiller(map[string]interface{}{"name": "cloney"})
+	filler.Workers = 4
+	err := filler.Render(context.Background(), source, []string{"ignored/"}, NewMemoryFS())
+	assert.Nil(err)
+	assert.Contains(buffer.String(), "Ignored 'ignored/'")
+	assert.Contains(buffer.String(), "Copied 'image.png'")
+	for index := 0; index < 20; index++ {
+		assert.Contains(buffer.String(), fmt.Sprintf("Rendered 'files/%02d.txt'", index))
+	}
+
+	buffer.Reset()
+	terminal.SetLogLevel(terminal.NORMAL_LOG_LEVEL)
+	err = filler.Render(context.Background(), source, []string{"ignored/"}, NewMemoryFS())
+	assert.Nil(err)
+	assert.Empty(buffer.String())
+}
@@ -0,0 +1,171 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// progressRefreshInterval is the minimum time between two updates of a progress line, so that it does not flicker.
+const progressRefreshInterval = 100 * time.Millisecond
+
+// progressBarWidth is the number of characters of a progress bar.
+const progressBarWidth = 30
+
+// activeProgress is the progress currently displayed, which is cleared before printing other messages.
+var activeProgress *Progress
+
+// isErrorTerminal returns true if the standard error is a terminal. It is a variable so that tests can replace it.
+var isErrorTerminal = IsErrorTerminal
+
+// Progress reports the progress of a long operation, such as rendering files, on a single line of the standard error.
+// It counts steps up to a total, or, if the total is zero, displays the last status written to it, such as the progress
+// messages of a git server. It is disabled, and its methods do nothing, if the standard error is not a terminal,
+// in the 'json' and 'yaml' output formats, in the quiet log level, or if no command is running.
+// The zero value is a disabled progress. It is safe to use it concurrently.
+type Progress struct {
+	// title describes the operation.
+	title string
+
+	// total is the number of steps of the operation, or zero if the progress is given by the status.
+	total int
+
+	// current is the number of steps done.
+	current int
+
+	// status is the last status written to the progress.
+	status string
+
+	// enabled is true if the progress is displayed, until it is done.
+	enabled bool
+
+	// drawn is true if the progress line is currently displayed.
+	drawn bool
+
+	// lastDraw is the time the progress line was last displayed.
+	lastDraw time.Time
+}
+
+// NewProgress creates a progress for an operation with the given number of steps, and starts displaying it if enabled.
+// 'Done' must be called once the operation is over, to clear the progress line.
+func NewProgress(title string, total int) *Progress {
+	mutex.Lock()
+	defer mutex.Unlock()
+	progress := &Progress{
+		title:   title,
+		total:   total,
+		enabled: cmd != nil && logLevel >= NORMAL_LOG_LEVEL && !IsStructuredOutput() && isErrorTerminal(),
+	}
+	if progress.enabled {
+		activeProgress = progress
+		progress.refresh(true)
+	}
+	return progress
+}
+
+// Enabled returns true if the progress is displayed.
+func (p *Progress) Enabled() bool {
+	return p.enabled
+}
+
+// Increment marks one more step as done.
+func (p *Progress) Increment() {
+	if !p.enabled {
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if activeProgress != p {
+		return
+	}
+	p.current++
+	p.refresh(p.current == p.total)
+}
+
+// Write sets the status of the progress to the last line written, so that the progress can be used as the
+// progress writer of git operations. It always succeeds.
+func (p *Progress) Write(data []byte) (int, error) {
+	if !p.enabled {
+		return len(data), nil
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if activeProgress != p {
+		return len(data), nil
+	}
+
+	// Git servers end their progress lines with '\r' while they are updated, and with '\n' once they are done.
+	lines := strings.FieldsFunc(string(data), func(r rune) bool {
+		return r == '\r' || r == '\n'
+	})
+	for index := len(lines) - 1; index >= 0; index-- {
+		if line := strings.TrimSpace(lines[index]); line != "" {
+			p.status = line
+			p.refresh(false)
+			break
+		}
+	}
+	return len(data), nil
+}
+
+// Done clears the progress line, so that the next messages are printed in its place.
+func (p *Progress) Done() {
+	if !p.enabled {
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if activeProgress == p {
+		p.clear()
+		activeProgress = nil
+	}
+}
+
+// refresh displays the progress line, unless it was displayed less than 'progressRefreshInterval' ago and 'force'
+// is false. The mutex must be locked.
+func (p *Progress) refresh(force bool) {
+	if !force && time.Since(p.lastDraw) < progressRefreshInterval {
+		return
+	}
+	p.lastDraw = time.Now()
+	p.draw()
+}
+
+// draw displays the progress line, truncated to the width of the terminal. The mutex must be locked.
+func (p *Progress) draw() {
+	line := fmt.Sprintf("%s...", p.title)
+	if p.total > 0 {
+		done := progressBarWidth * p.current / p.total
+		bar := strings.Repeat("=", done) + strings.Repeat(" ", progressBarWidth-done)
+		line = fmt.Sprintf("%s [%s] %d/%d", p.title, bar, p.current, p.total)
+	} else if p.status != "" {
+		line = fmt.Sprintf("%s: %s", p.title, p.status)
+	}
+	if width, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil {
+		line = truncateLine(line, width)
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+	p.drawn = true
+}
+
+// truncateLine truncates a line to less than 'width' characters, so that the cursor stays on the same line.
+// Lines are truncated by runes, so that multi-byte characters are never split.
+func truncateLine(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) < width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:width-1])
+}
+
+// clear erases the progress line, if it is displayed. The mutex must be locked.
+func (p *Progress) clear() {
+	if p.drawn {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		p.drawn = false
+	}
+}
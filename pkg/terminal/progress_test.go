@@ -0,0 +1,119 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// setUpProgressTest simulates a running command whose standard error is a file, which is displayed as a terminal
+// if 'errorTerminal' is true. It returns the file, and restores the terminal settings after the test.
+func setUpProgressTest(t *testing.T, errorTerminal bool) *os.File {
+	stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	assert.NoError(t, err)
+
+	previousStderr, previousIsErrorTerminal := os.Stderr, isErrorTerminal
+	os.Stderr = stderr
+	isErrorTerminal = func() bool { return errorTerminal }
+	SetCmd(&cobra.Command{})
+	t.Cleanup(func() {
+		os.Stderr, isErrorTerminal = previousStderr, previousIsErrorTerminal
+		SetCmd(nil)
+		SetLogLevel(NORMAL_LOG_LEVEL)
+		SetOutputFormat(TEXT_OUTPUT_FORMAT)
+		activeProgress = nil
+		stderr.Close()
+	})
+	return stderr
+}
+
+// readStderr returns what was written to the standard error file.
+func readStderr(t *testing.T, stderr *os.File) string {
+	content, err := os.ReadFile(stderr.Name())
+	assert.NoError(t, err)
+	return string(content)
+}
+
+// TestProgressIsDisabled tests creating a progress when the standard error is not a terminal, in the quiet log level
+// and in the 'json' output format. It should be disabled and print nothing.
+func TestProgressIsDisabled(t *testing.T) {
+	tests := map[string]struct {
+		errorTerminal bool
+		setUp         func()
+	}{
+		"not a terminal": {errorTerminal: false, setUp: func() {}},
+		"quiet":          {errorTerminal: true, setUp: func() { SetLogLevel(QUIET_LOG_LEVEL) }},
+		"json output":    {errorTerminal: true, setUp: func() { SetOutputFormat(JSON_OUTPUT_FORMAT) }},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Create a new testing.T instance to use with assert functions.
+			assert := assert.New(t)
+			stderr := setUpProgressTest(t, test.errorTerminal)
+			test.setUp()
+
+			progress := NewProgress("Rendering files", 2)
+			progress.Increment()
+			progress.Write([]byte("Counting objects: 100%\n"))
+			progress.Done()
+
+			assert.False(progress.Enabled())
+			assert.Empty(readStderr(t, stderr))
+		})
+	}
+}
+
+// TestProgressIsEnabled tests creating a progress when the standard error is a terminal.
+// It should display the progress bar, and clear it once done.
+func TestProgressIsEnabled(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+	stderr := setUpProgressTest(t, true)
+
+	progress := NewProgress("Rendering files", 2)
+	assert.True(progress.Enabled())
+	progress.Increment()
+	progress.Increment()
+	progress.Done()
+
+	output := readStderr(t, stderr)
+	assert.Contains(output, "Rendering files [")
+	assert.Contains(output, "] 2/2")
+	assert.Contains(output, "\r\033[K")
+	assert.Nil(activeProgress)
+}
+
+// TestProgressWriteKeepsLastStatus tests writing the progress messages of a git server, separated by '\r' while they
+// are updated and ended by '\n'. The status should be the last non-empty message.
+func TestProgressWriteKeepsLastStatus(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+	setUpProgressTest(t, true)
+
+	progress := NewProgress("Fetching", 0)
+	defer progress.Done()
+
+	data := []byte("Counting objects:  10% (1/10)\rCounting objects:  50% (5/10)\r")
+	n, err := progress.Write(data)
+	assert.NoError(err)
+	assert.Equal(len(data), n)
+	assert.Equal("Counting objects:  50% (5/10)", progress.status)
+
+	progress.Write([]byte("Counting objects: 100% (10/10), done.\n\r  \n"))
+	assert.Equal("Counting objects: 100% (10/10), done.", progress.status)
+}
+
+// TestTruncateLine tests truncating progress lines to the width of the terminal.
+// Lines should be truncated by runes, never splitting multi-byte characters.
+func TestTruncateLine(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+
+	assert.Equal("Fetching", truncateLine("Fetching", 20))
+	assert.Equal("Fetching", truncateLine("Fetching", 0))
+	assert.Equal("Fetc", truncateLine("Fetching", 5))
+	assert.Equal("Écrit: ✓✓", truncateLine("Écrit: ✓✓✓✓", 10))
+}
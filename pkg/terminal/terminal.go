@@ -3,10 +3,20 @@ package terminal
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
 )
 
 // Colors for terminal output.
@@ -20,120 +30,309 @@ var (
 	WhiteBoldUnderline = color.New(color.FgWhite, color.Bold, color.Underline).SprintFunc()
 )
 
+// mutex guards the command, the log level, the colors and the secrets, so messages can be printed concurrently.
+var mutex sync.Mutex
+
 // cmd is the current command being executed.
 var cmd *cobra.Command
 
 // testBuffer is a buffer used for testing.
 var testBuffer *bytes.Buffer
 
+// outputColors and errorColors are false if colors must be removed from the messages printed to the standard output
+// and to the standard error, respectively.
+var outputColors, errorColors = true, true
+
+// colorSequence matches the ANSI escape sequences of colors.
+var colorSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Constants for the output formats of the commands.
+const (
+	TEXT_OUTPUT_FORMAT = "text"
+	JSON_OUTPUT_FORMAT = "json"
+	YAML_OUTPUT_FORMAT = "yaml"
+)
+
+// SupportedOutputFormats is the list of supported output formats.
+var SupportedOutputFormats = []string{
+	TEXT_OUTPUT_FORMAT,
+	JSON_OUTPUT_FORMAT,
+	YAML_OUTPUT_FORMAT,
+}
+
+// outputFormat is the output format of the current command.
+var outputFormat = TEXT_OUTPUT_FORMAT
+
+// LogLevel is the level of detail of the messages printed to the terminal.
+type LogLevel int
+
+// Constants for the log levels, from the least to the most detailed.
+const (
+	// QUIET_LOG_LEVEL only prints errors and the result of the commands.
+	QUIET_LOG_LEVEL LogLevel = iota
+
+	// NORMAL_LOG_LEVEL prints the progress of the commands, with warnings.
+	NORMAL_LOG_LEVEL
+
+	// VERBOSE_LOG_LEVEL also prints details about each step, such as the repositories fetched.
+	VERBOSE_LOG_LEVEL
+
+	// DEBUG_LOG_LEVEL also prints what happens to each file: ignored, copied, rendered or removed.
+	DEBUG_LOG_LEVEL
+)
+
+// logLevel is the log level of the current command.
+var logLevel = NORMAL_LOG_LEVEL
+
+// lowered is true while the log level is lowered with 'LowerLogLevel'.
+var lowered bool
+
+// RedactedValue is the text that replaces secret values in the terminal output.
+const RedactedValue = "********"
+
+// secrets is the list of secret values that must never be printed in error messages.
+var secrets []string
+
+// AddSecrets registers secret values, which are replaced by "********" in error messages, where they may be quoted
+// by the errors of other packages. Empty values, booleans and numbers are ignored, since redacting them would also
+// redact unrelated text, such as line numbers. Other messages must not contain secret values: they are redacted
+// before being formatted, such as with 'CloneyMetadata.RedactSecrets'.
+func AddSecrets(values ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, value := range values {
+		if !isAmbiguousSecret(value) {
+			secrets = append(secrets, value)
+		}
+	}
+	// Replace longer secrets first, so secrets containing other secrets are fully redacted.
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+}
+
+// isAmbiguousSecret returns true if a secret value is empty, a boolean or a number.
+func isAmbiguousSecret(value string) bool {
+	if _, err := strconv.ParseBool(value); err == nil {
+		return true
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return true
+	}
+	return value == ""
+}
+
+// Redact replaces the registered secret values in an error text by "********".
+func Redact(text string) string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, RedactedValue)
+	}
+	return text
+}
+
 // SetCmd sets the current command, allowing messages to be printed to the command's output using cmd.Print().
 func SetCmd(newCommand *cobra.Command) {
+	mutex.Lock()
+	defer mutex.Unlock()
 	cmd = newCommand
 }
 
+// SetLogLevel sets the level of detail of the messages printed to the terminal.
+func SetLogLevel(level LogLevel) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	logLevel = level
+}
+
+// LowerLogLevel lowers the log level by one, until the returned function is called to restore it.
+// Commands use it so that the messages and the progress of the steps they run are only printed in the more detailed
+// log levels. Until then, messages are printed to the error output, since they are not the result of the command.
+// It is safe to call the returned function more than once.
+func LowerLogLevel() (restore func()) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	previousLevel, previousLowered := logLevel, lowered
+	if logLevel > QUIET_LOG_LEVEL {
+		logLevel--
+	}
+	lowered = true
+	return func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		logLevel, lowered = previousLevel, previousLowered
+	}
+}
+
+// SetColors enables or disables colors in the terminal output. Colors are always disabled if the 'NO_COLOR'
+// environment variable is set, and they are disabled for the standard output and the standard error separately
+// if they are not a terminal, such as when they are redirected to a file.
+func SetColors(enabled bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	enabled = enabled && os.Getenv("NO_COLOR") == ""
+	outputColors = enabled && IsOutputTerminal()
+	errorColors = enabled && IsErrorTerminal()
+
+	// Messages are colored before knowing where they are printed, and the colors are removed when they are printed
+	// to a stream without colors.
+	color.NoColor = !outputColors && !errorColors
+}
+
 // SetTestMode sets the terminal to test mode, allowing messages to be printed to a buffer.
 func SetTestMode(newTestBuffer *bytes.Buffer) {
+	mutex.Lock()
+	defer mutex.Unlock()
 	testBuffer = newTestBuffer
 }
 
-// Messages functions.
+// SetOutputFormat sets the output format of the current command.
+// In the 'json' and 'yaml' formats, the result of the command is printed with 'Output' to the standard output,
+// and every other message is printed to the standard error, so that the standard output can be parsed.
+func SetOutputFormat(format string) error {
+	if !slices.Contains(SupportedOutputFormats, format) {
+		return fmt.Errorf("unsupported output format '%s', expected one of: %s", format, strings.Join(SupportedOutputFormats, ", "))
+	}
+	outputFormat = format
+	return nil
+}
 
-// Message prints a message with no prefix.
-func Message(message string) {
-	if cmd != nil {
-		str := fmt.Sprintf("%s\n", message)
+// IsStructuredOutput returns true if the output format is 'json' or 'yaml'.
+func IsStructuredOutput() bool {
+	return outputFormat != TEXT_OUTPUT_FORMAT
+}
 
-		// Print the message to the terminal.
-		cmd.SetOut(cmd.OutOrStdout())
-		cmd.Print(str)
+// Output prints the result of a command in the 'json' or 'yaml' output format. Secret values must be redacted before,
+// such as with 'CloneyMetadata.RedactSecrets', since they may be escaped once formatted.
+// It does nothing in the 'text' output format, where the result is printed with the other messages.
+func Output(value interface{}) error {
+	var content []byte
+	var err error
+	switch outputFormat {
+	case JSON_OUTPUT_FORMAT:
+		content, err = json.MarshalIndent(value, "", "  ")
+		content = append(content, '\n')
+	case YAML_OUTPUT_FORMAT:
+		content, err = yaml.Marshal(value)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not format the result as %s: %w", outputFormat, err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
 
-		// If in test mode, write to the buffer as well.
-		if testBuffer != nil {
-			cmd.SetOut(testBuffer)
-			cmd.Print(str)
-		}
+	// Print the result to the terminal, and to the buffer in test mode.
+	os.Stdout.Write(content)
+	if testBuffer != nil {
+		testBuffer.Write(content)
 	}
+	return nil
 }
 
-// Messagef prints a formatted message with no prefix.
-func Messagef(format string, a ...interface{}) {
-	if cmd != nil {
-		str := fmt.Sprintf(format, a...)
+// Messages functions.
 
-		// Print the message to the terminal.
-		cmd.SetOut(cmd.OutOrStdout())
-		cmd.Print(str)
+// printMessage prints a message of the given log level to the command's output, or to its error output if 'toStderr'
+// is true, and to the buffer in test mode. Messages more detailed than the log level are not printed.
+// It is safe to call it concurrently.
+func printMessage(level LogLevel, toStderr bool, str string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if cmd == nil || level > logLevel {
+		return
+	}
 
-		// If in test mode, write to the buffer as well.
-		if testBuffer != nil {
-			cmd.SetOut(testBuffer)
-			cmd.Print(str)
-		}
+	// The progress line is erased before the message, and displayed again after it.
+	if activeProgress != nil {
+		activeProgress.clear()
+		defer activeProgress.draw()
 	}
+
+	// In the 'json' and 'yaml' output formats, the standard output only holds the result of the command.
+	if IsStructuredOutput() {
+		fmt.Fprint(os.Stderr, withColors(str, errorColors))
+		return
+	}
+	writeString(toStderr || lowered, str)
 }
 
-// OKMessage prints a success message with a green "[OK]" prefix.
-func OKMessage(message string) {
-	if cmd != nil {
-		str := fmt.Sprintf("[%s] %s\n", Green("OK"), message)
+// writeString prints a text to the command's output, or to its error output if 'toStderr' is true,
+// and to the buffer in test mode. The mutex must be locked.
+func writeString(toStderr bool, str string) {
+	// Print the text to the terminal.
+	if toStderr {
+		str = withColors(str, errorColors)
+		fmt.Fprint(cmd.ErrOrStderr(), str)
+	} else {
+		str = withColors(str, outputColors)
+		fmt.Fprint(cmd.OutOrStdout(), str)
+	}
 
-		// Print the message to the terminal.
-		cmd.SetOut(cmd.OutOrStdout())
-		cmd.Print(str)
+	// If in test mode, write to the buffer as well.
+	if testBuffer != nil {
+		testBuffer.WriteString(str)
+	}
+}
 
-		// If in test mode, write to the buffer as well.
-		if testBuffer != nil {
-			cmd.SetOut(testBuffer)
-			cmd.Print(str)
-		}
+// withColors removes the colors from a text, unless 'colors' is true.
+func withColors(str string, colors bool) string {
+	if colors {
+		return str
 	}
+	return colorSequence.ReplaceAllString(str, "")
 }
 
-// WarningMessage prints a warning message with a yellow "[Warning]" prefix.
-func WarningMessage(message string) {
-	if cmd != nil {
-		str := fmt.Sprintf("[%s] %s\n", Yellow("Warning"), message)
+// Message prints a message with no prefix.
+func Message(message string) {
+	printMessage(NORMAL_LOG_LEVEL, false, fmt.Sprintf("%s\n", message))
+}
 
-		// Print the message to the terminal.
-		cmd.SetOut(cmd.OutOrStdout())
-		cmd.Print(str)
+// Messagef prints a formatted message with no prefix.
+func Messagef(format string, a ...interface{}) {
+	printMessage(NORMAL_LOG_LEVEL, false, fmt.Sprintf(format, a...))
+}
 
-		// If in test mode, write to the buffer as well.
-		if testBuffer != nil {
-			cmd.SetOut(testBuffer)
-			cmd.Print(str)
-		}
-	}
+// OKMessage prints a success message with a green "[OK]" prefix.
+func OKMessage(message string) {
+	printMessage(NORMAL_LOG_LEVEL, false, fmt.Sprintf("[%s] %s\n", Green("OK"), message))
 }
 
-// ErrorMessage prints an error message with a red "[Error]" prefix.
+// WarningMessage prints a warning message with a yellow "[Warning]" prefix.
+func WarningMessage(message string) {
+	printMessage(NORMAL_LOG_LEVEL, false, fmt.Sprintf("[%s] %s\n", Yellow("Warning"), message))
+}
+
+// ErrorMessage prints an error message with a red "[Error]" prefix. Errors are printed at every log level.
+// Secret values are redacted, since errors may quote them.
 func ErrorMessage(message string, err error) {
-	if cmd != nil {
-		str := ""
-		if err != nil {
-			str = fmt.Sprintf("[%s] %s: %v\n", Red("Error"), message, err)
-		} else {
-			str = fmt.Sprintf("[%s] %s\n", Red("Error"), message)
-		}
+	str := ""
+	if err != nil {
+		str = fmt.Sprintf("[%s] %s: %v\n", Red("Error"), message, err)
+	} else {
+		str = fmt.Sprintf("[%s] %s\n", Red("Error"), message)
+	}
+	printMessage(QUIET_LOG_LEVEL, true, Redact(str))
+}
 
-		// Print the message to the terminal.
-		cmd.SetOut(cmd.ErrOrStderr())
-		cmd.Print(str)
+// VerboseMessage prints a message with a blue "[Info]" prefix to the error output, in the verbose and debug log levels.
+func VerboseMessage(message string) {
+	printMessage(VERBOSE_LOG_LEVEL, true, fmt.Sprintf("[%s] %s\n", Blue("Info"), message))
+}
 
-		// If in test mode, write to the buffer as well.
-		if testBuffer != nil {
-			cmd.SetOut(testBuffer)
-			cmd.Print(str)
-		}
-	}
+// DebugMessage prints a message with a "[Debug]" prefix to the error output, in the debug log level.
+func DebugMessage(message string) {
+	printMessage(DEBUG_LOG_LEVEL, true, fmt.Sprintf("[Debug] %s\n", message))
 }
 
 // InputWithDefaultValue prompts the user for input via terminal and returns the input value or the default value.
 func InputWithDefaultValue(scanner *bufio.Scanner, message, defaultValue string) string {
+	mutex.Lock()
 	if cmd != nil {
-		cmd.SetOut(cmd.OutOrStdout())
-		cmd.Print(fmt.Sprintf("%s [%s]: ", message, Blue(defaultValue)))
+		writeString(false, fmt.Sprintf("%s [%s]: ", message, Blue(defaultValue)))
 	}
+	mutex.Unlock()
 	scanner.Scan()
 	input := scanner.Text()
 	if input == "" {
@@ -141,3 +340,37 @@ func InputWithDefaultValue(scanner *bufio.Scanner, message, defaultValue string)
 	}
 	return input
 }
+
+// IsOutputTerminal returns true if the standard output is a terminal, meaning colors can be displayed.
+func IsOutputTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// IsErrorTerminal returns true if the standard error is a terminal, meaning progress can be displayed.
+func IsErrorTerminal() bool {
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// IsInputTerminal returns true if the standard input is a terminal, meaning the user can be prompted for input.
+func IsInputTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// InputSecret prompts the user for a secret value via terminal, without echoing the typed characters.
+func InputSecret(message string) (string, error) {
+	mutex.Lock()
+	if cmd != nil {
+		writeString(false, fmt.Sprintf("%s: ", message))
+	}
+	mutex.Unlock()
+	input, err := term.ReadPassword(int(os.Stdin.Fd()))
+	mutex.Lock()
+	if cmd != nil {
+		writeString(false, "\n")
+	}
+	mutex.Unlock()
+	if err != nil {
+		return "", err
+	}
+	return string(input), nil
+}
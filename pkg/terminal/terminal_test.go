@@ -0,0 +1,106 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// setUpMessagesTest simulates a running command whose output and error output are buffers, which are returned.
+// It restores the terminal settings after the test.
+func setUpMessagesTest(t *testing.T) (*bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	command := &cobra.Command{}
+	command.SetOut(&stdout)
+	command.SetErr(&stderr)
+	SetCmd(command)
+
+	previousNoColor := color.NoColor
+	t.Cleanup(func() {
+		SetCmd(nil)
+		SetLogLevel(NORMAL_LOG_LEVEL)
+		lowered = false
+		outputColors, errorColors = true, true
+		color.NoColor = previousNoColor
+	})
+	return &stdout, &stderr
+}
+
+// TestRedactSecrets tests redacting registered secret values in an error text.
+// Short values should be redacted, but empty values, booleans and numbers should be ignored.
+func TestRedactSecrets(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+	defer func() { secrets = nil }()
+
+	AddSecrets("abc", "abc-long-token", "", "true", "1", "8080", "1.5")
+
+	assert.Equal("token ******** and ******** on line 1", Redact("token abc-long-token and abc on line 1"))
+	assert.Equal("true 8080 1.5", Redact("true 8080 1.5"))
+}
+
+// TestMessagesAfterVerboseMessage tests printing a normal message after a verbose message, which is printed to the
+// error output. The normal message should still be printed to the output.
+func TestMessagesAfterVerboseMessage(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+	stdout, stderr := setUpMessagesTest(t)
+	SetLogLevel(VERBOSE_LOG_LEVEL)
+
+	VerboseMessage("Fetching the template")
+	Message("Template information")
+	ErrorMessage("Could not render the template", nil)
+	Message("Done")
+
+	assert.Equal("Template information\nDone\n", stdout.String())
+	assert.Contains(stderr.String(), "Fetching the template")
+	assert.Contains(stderr.String(), "Could not render the template")
+	assert.NotContains(stderr.String(), "Done")
+}
+
+// TestColorsPerStream tests printing colored messages when only the output is a terminal.
+// The colors should be removed from the messages printed to the error output only.
+func TestColorsPerStream(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+	stdout, stderr := setUpMessagesTest(t)
+	outputColors, errorColors = true, false
+	color.NoColor = false
+
+	OKMessage("Cloned")
+	ErrorMessage("Could not clone", nil)
+
+	assert.Contains(stdout.String(), "\x1b[")
+	assert.Equal("[Error] Could not clone\n", stderr.String())
+}
+
+// TestLowerLogLevel tests lowering the log level while running steps, and restoring it.
+// Normal messages should only be printed in the verbose log level while it is lowered, to the error output.
+func TestLowerLogLevel(t *testing.T) {
+	// Create a new testing.T instance to use with assert functions.
+	assert := assert.New(t)
+	stdout, stderr := setUpMessagesTest(t)
+
+	restore := LowerLogLevel()
+	OKMessage("The template repository metadata file was found")
+	restore()
+	restore()
+	Message("Template information")
+
+	assert.Equal("Template information\n", stdout.String())
+	assert.Empty(stderr.String())
+	assert.Equal(NORMAL_LOG_LEVEL, logLevel)
+
+	// In the verbose log level, the messages of the steps are printed to the error output.
+	SetLogLevel(VERBOSE_LOG_LEVEL)
+	restore = LowerLogLevel()
+	OKMessage("The template repository metadata file was found")
+	restore()
+
+	assert.Equal("Template information\n", stdout.String())
+	assert.Contains(stderr.String(), "The template repository metadata file was found")
+	assert.Equal(VERBOSE_LOG_LEVEL, logLevel)
+}